@@ -0,0 +1,61 @@
+#!/usr/bin/env python3
+# A minimal snake bot: heads for the apple and avoids walls and its own body.
+# Run it with: go run . -game snake -bot "python3 bots/snake_bot.py"
+import json
+import sys
+
+MOVES = {"up": (0, 1), "right": (1, 0), "down": (0, -1), "left": (-1, 0)}
+REVERSE = {"up": "down", "right": "left", "down": "up", "left": "right",
+           "up-right": "down-left", "down-right": "up-left", "down-left": "up-right", "up-left": "down-right"}
+# on hex cells the odd columns are half a cell higher, so going sideways from one goes up a row
+HEX_MOVES = {"up": (0, 1, 1), "up-right": (1, 1, 0), "down-right": (1, 0, -1),
+             "down": (0, -1, -1), "down-left": (-1, 0, -1), "up-left": (-1, 1, 0)}
+
+
+def step(x, y, name, hex_cells):
+    if hex_cells:
+        dx, odd_dy, even_dy = HEX_MOVES[name]
+        return x + dx, y + (odd_dy if x % 2 else even_dy)
+    dx, dy = MOVES[name]
+    return x + dx, y + dy
+
+
+def distance(a, b, hex_cells):
+    if hex_cells:
+        # counting rows along the slope of the columns gives the hex distance a closed form
+        dq, dr = b[0] - a[0], (b[1] - (b[0] >> 1)) - (a[1] - (a[0] >> 1))
+        return (abs(dq) + abs(dr) + abs(dq + dr)) // 2
+    return abs(a[0] - b[0]) + abs(a[1] - b[1])
+
+
+for line in sys.stdin:
+    state = json.loads(line)
+    if state["type"] != "tick":
+        continue
+    hex_cells = state.get("hex", False)
+    head = tuple(state["body"][0])
+    apple = tuple(state["apple"])
+    # the tail moves away this step, but the snake can't enter its cell in the same step
+    blocked = {tuple(p) for p in state["body"] + state.get("walls", []) + state.get("hazards", [])}
+    # stepping on a portal takes the snake out past its partner
+    partner = {}
+    for a, b in state.get("portals", []):
+        partner[tuple(a)], partner[tuple(b)] = tuple(b), tuple(a)
+    best, best_dist = state["direction"], None
+    for name in HEX_MOVES if hex_cells else MOVES:
+        x, y = step(*head, name, hex_cells)
+        if state.get("wrap"):
+            # without walls the snake comes back on the opposite side
+            x, y = x % state["width"], y % state["height"]
+        if (x, y) in partner:
+            x, y = step(*partner[(x, y)], name, hex_cells)
+            if state.get("wrap"):
+                x, y = x % state["width"], y % state["height"]
+        if name == REVERSE[state["direction"]] or (x, y) in blocked:
+            continue
+        if not (0 <= x < state["width"] and 0 <= y < state["height"]):
+            continue
+        dist = distance((x, y), apple, hex_cells)
+        if best_dist is None or dist < best_dist:
+            best, best_dist = name, dist
+    print(json.dumps({"tick": state["tick"], "direction": best}), flush=True)
@@ -0,0 +1,30 @@
+// Command leaderboard serves the games' leaderboard over HTTP, storing the scores in SQLite
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"log"
+	"net/http"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/miluchen/games-in-go/games/leaderboard"
+)
+
+var addr = flag.String("addr", ":8080", "address to serve the leaderboard on")
+var dbFile = flag.String("db", "leaderboard.db", "SQLite file the scores are stored in")
+
+func main() {
+	flag.Parse()
+	db, err := sql.Open("sqlite3", *dbFile)
+	if err != nil {
+		log.Fatalf("open db failed: %v\n", err)
+	}
+	defer db.Close()
+	server, err := leaderboard.NewServer(db)
+	if err != nil {
+		log.Fatalf("create leaderboard failed: %v\n", err)
+	}
+	log.Printf("serving the leaderboard on %s\n", *addr)
+	log.Fatal(http.ListenAndServe(*addr, server))
+}
@@ -0,0 +1,147 @@
+package boardgames
+
+import "github.com/miluchen/games-in-go/games/search"
+
+const (
+	c4Cols    = 7
+	c4Rows    = 6
+	c4Connect = 4 // number of discs in a row to win
+)
+
+// weights of a window of 4 cells holding 1, 2 or 3 discs of a single player
+var c4WindowWeights = []int{0, 1, 10, 50}
+
+// ConnectFour is played on a 7x6 board, a move is the column the disc is dropped into
+type ConnectFour struct {
+	cells   [c4Cols][c4Rows]Player
+	heights [c4Cols]int // number of discs in each column
+	history []int       // moves made so far
+	winLine [][2]int
+}
+
+func NewConnectFour() *ConnectFour {
+	return &ConnectFour{}
+}
+
+func (c *ConnectFour) Name() string {
+	return "Connect Four"
+}
+
+func (c *ConnectFour) Size() (int, int) {
+	return c4Cols, c4Rows
+}
+
+func (c *ConnectFour) At(x, y int) Player {
+	if x < 0 || x >= c4Cols || y < 0 || y >= c4Rows {
+		return None
+	}
+	return c.cells[x][y]
+}
+
+func (c *ConnectFour) Turn() Player {
+	if len(c.history)%2 == 0 {
+		return First
+	}
+	return Second
+}
+
+func (c *ConnectFour) Winner() Player {
+	if c.winLine == nil {
+		return None
+	}
+	return c.Turn().Other()
+}
+
+func (c *ConnectFour) WinLine() [][2]int {
+	return c.winLine
+}
+
+func (c *ConnectFour) Moves() []int {
+	if c.winLine != nil {
+		return nil
+	}
+	var moves []int
+	// center columns first, they take part in more lines
+	for _, x := range []int{3, 2, 4, 1, 5, 0, 6} {
+		if c.heights[x] < c4Rows {
+			moves = append(moves, x)
+		}
+	}
+	return moves
+}
+
+func (c *ConnectFour) Play(move int) {
+	y := c.heights[move]
+	c.cells[move][y] = c.Turn()
+	c.heights[move]++
+	c.history = append(c.history, move)
+	c.winLine = findLine(c.At, move, y, c4Connect)
+}
+
+func (c *ConnectFour) Undo() {
+	move := c.history[len(c.history)-1]
+	c.history = c.history[:len(c.history)-1]
+	c.heights[move]--
+	c.cells[move][c.heights[move]] = None
+	c.winLine = nil
+}
+
+// Evaluate scores every window of 4 cells that only one player has discs in, a full board is a draw
+func (c *ConnectFour) Evaluate() int {
+	if c.winLine != nil {
+		return -search.Win
+	}
+	if len(c.history) == c4Cols*c4Rows {
+		// a full board without a line is a draw
+		return 0
+	}
+	me := c.Turn()
+	score := 0
+	for x := 0; x < c4Cols; x++ {
+		for y := 0; y < c4Rows; y++ {
+			for _, d := range [][2]int{{1, 0}, {0, 1}, {1, 1}, {1, -1}} {
+				ex, ey := x+d[0]*(c4Connect-1), y+d[1]*(c4Connect-1)
+				if ex < 0 || ex >= c4Cols || ey < 0 || ey >= c4Rows {
+					continue
+				}
+				mine, theirs := 0, 0
+				for i := 0; i < c4Connect; i++ {
+					switch c.cells[x+d[0]*i][y+d[1]*i] {
+					case me:
+						mine++
+					case me.Other():
+						theirs++
+					}
+				}
+				if theirs == 0 {
+					score += c4WindowWeights[mine]
+				} else if mine == 0 {
+					score -= c4WindowWeights[theirs]
+				}
+			}
+		}
+	}
+	return score
+}
+
+// MoveAt drops a disc into the clicked column, whatever the row
+func (c *ConnectFour) MoveAt(x, y int) (int, bool) {
+	if x < 0 || x >= c4Cols || c.winLine != nil || c.heights[x] == c4Rows {
+		return 0, false
+	}
+	return x, true
+}
+
+func (c *ConnectFour) LastMove() (int, int, bool) {
+	if len(c.history) == 0 {
+		return 0, 0, false
+	}
+	move := c.history[len(c.history)-1]
+	return move, c.heights[move] - 1, true
+}
+
+func (c *ConnectFour) Clone() Game {
+	clone := *c
+	clone.history = append([]int(nil), c.history...)
+	return &clone
+}
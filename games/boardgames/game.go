@@ -0,0 +1,63 @@
+package boardgames
+
+import "github.com/miluchen/games-in-go/games/search"
+
+type Player int
+
+const (
+	None Player = iota
+	First
+	Second
+)
+
+// Other returns the opponent of p
+func (p Player) Other() Player {
+	return 3 - p
+}
+
+// Game is a board game on a grid that can be played by humans or searched by the AI
+type Game interface {
+	search.State
+	// Name returns the name of the game
+	Name() string
+	// Size returns the number of columns and rows of the board
+	Size() (cols, rows int)
+	// At returns the player owning the cell at column x and row y, row 0 is the bottom row
+	At(x, y int) Player
+	// Turn returns the player to move
+	Turn() Player
+	// Winner returns the winner, or None if there is none yet or the game is a draw
+	Winner() Player
+	// WinLine returns the cells of the winning line
+	WinLine() [][2]int
+	// MoveAt returns the move for clicking the cell (x, y), ok is false if it's not legal
+	MoveAt(x, y int) (move int, ok bool)
+	// LastMove returns the cell of the last move, ok is false if no move was made
+	LastMove() (x, y int, ok bool)
+	// Clone returns a copy of the game that can be searched while this one is drawn
+	Clone() Game
+}
+
+// findLine returns the line of at least n cells of the same player through (x, y),
+// at returns None for cells outside the board
+func findLine(at func(x, y int) Player, x, y, n int) [][2]int {
+	p := at(x, y)
+	if p == None {
+		return nil
+	}
+	for _, d := range [][2]int{{1, 0}, {0, 1}, {1, 1}, {1, -1}} {
+		// walk back to the start of the line, then collect it forwards
+		sx, sy := x, y
+		for at(sx-d[0], sy-d[1]) == p {
+			sx, sy = sx-d[0], sy-d[1]
+		}
+		var line [][2]int
+		for cx, cy := sx, sy; at(cx, cy) == p; cx, cy = cx+d[0], cy+d[1] {
+			line = append(line, [2]int{cx, cy})
+		}
+		if len(line) >= n {
+			return line
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package boardgames
+
+import (
+	"fmt"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/colornames"
+)
+
+// games that can be chosen in the setup menu, with the max AI depth of each
+var games = []struct {
+	new      func() Game
+	maxDepth int
+}{
+	{func() Game { return NewTicTacToe() }, 9},
+	{func() Game { return NewConnectFour() }, 10},
+}
+
+const defaultDepth = 4
+
+// SetupMenu chooses the game, the opponent and the strength of the AI
+type SetupMenu struct {
+	game       int  // index in games
+	vsComputer bool // whether the second player is the computer
+	aiFirst    bool // whether the computer moves first
+	depth      int  // number of moves the AI searches ahead
+}
+
+func newSetupMenu() *SetupMenu {
+	return &SetupMenu{vsComputer: true, depth: defaultDepth}
+}
+
+func (m *SetupMenu) newGame() Game {
+	return games[m.game].new()
+}
+
+// aiPlayer returns the player controlled by the computer, or None
+func (m *SetupMenu) aiPlayer() Player {
+	if !m.vsComputer {
+		return None
+	}
+	if m.aiFirst {
+		return First
+	}
+	return Second
+}
+
+func (m *SetupMenu) update(win *pixelgl.Window) {
+	switch {
+	case win.JustPressed(pixelgl.KeyEscape):
+		gameState = Exit
+		return
+	case win.JustPressed(pixelgl.KeyEnter):
+		startGame()
+		return
+	case win.JustPressed(pixelgl.KeyG):
+		m.game = (m.game + 1) % len(games)
+		m.depth = min(m.depth, games[m.game].maxDepth)
+	case win.JustPressed(pixelgl.KeyO):
+		m.vsComputer = !m.vsComputer
+	case win.JustPressed(pixelgl.KeyF):
+		m.aiFirst = !m.aiFirst
+	case win.JustPressed(pixelgl.KeyEqual) || win.JustPressed(pixelgl.KeyKPAdd):
+		m.depth = min(games[m.game].maxDepth, m.depth+1)
+	case win.JustPressed(pixelgl.KeyMinus) || win.JustPressed(pixelgl.KeyKPSubtract):
+		m.depth = max(1, m.depth-1)
+	}
+	m.draw(win)
+}
+
+func (m *SetupMenu) draw(win *pixelgl.Window) {
+	win.Clear(colornames.Gray)
+	opponent := "Human"
+	if m.vsComputer {
+		opponent = "Computer"
+	}
+	first := "You"
+	if m.aiFirst {
+		first = "Computer"
+	}
+	txt := text.New(pixel.ZV, atlas)
+	txt.Color = colornames.Green
+	fmt.Fprint(txt, "Board Games\n\n")
+	txt.Color = colornames.White
+	fmt.Fprintf(txt, "G  Game:        %s\n", m.newGame().Name())
+	fmt.Fprintf(txt, "O  Opponent:    %s\n", opponent)
+	if m.vsComputer {
+		fmt.Fprintf(txt, "F  First move:  %s\n", first)
+		fmt.Fprintf(txt, "+- AI depth:    %d\n", m.depth)
+	}
+	txt.Color = colornames.Lightgray
+	fmt.Fprint(txt, "\nEnter: play  Esc: exit")
+	txt.Draw(win, pixel.IM.Scaled(pixel.ZV, 1.5).Moved(pixel.V(60, win.Bounds().H()-80)))
+}
@@ -0,0 +1,208 @@
+package boardgames
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"github.com/miluchen/games-in-go/games/search"
+	"golang.org/x/image/colornames"
+)
+
+const margin = 40 // space around the board in pixels
+
+type Scene struct {
+	game     Game
+	ai       Player   // player controlled by the computer, None if both are human
+	depth    int      // number of moves the AI searches ahead
+	thinking bool     // whether the AI is searching
+	aiMove   chan int // the move found by the AI
+}
+
+func newScene(game Game, ai Player, depth int) *Scene {
+	return &Scene{game: game, ai: ai, depth: depth, aiMove: make(chan int, 1)}
+}
+
+func (s *Scene) over() bool {
+	return len(s.game.Moves()) == 0
+}
+
+func (s *Scene) update(win *pixelgl.Window) {
+	if win.JustPressed(pixelgl.KeyEscape) {
+		backToMenu()
+		return
+	}
+	if s.thinking {
+		select {
+		case move := <-s.aiMove:
+			s.game.Play(move)
+			s.thinking = false
+		default:
+		}
+	} else if win.JustPressed(pixelgl.KeyU) {
+		s.undo()
+	} else if win.JustPressed(pixelgl.KeyR) {
+		startGame()
+		return
+	} else if !s.over() {
+		if s.game.Turn() == s.ai {
+			s.think()
+		} else if win.JustPressed(pixelgl.MouseButtonLeft) {
+			if move, ok := s.hoveredMove(win); ok {
+				s.game.Play(move)
+			}
+		}
+	}
+	s.draw(win)
+}
+
+// think searches the AI move on a copy of the game, so the board can still be drawn meanwhile
+func (s *Scene) think() {
+	s.thinking = true
+	game, depth, result := s.game.Clone(), s.depth, s.aiMove
+	go func() {
+		move, _, _ := search.Best(game, depth)
+		result <- move
+	}()
+}
+
+// undo takes back the last move, or the last two moves against the computer so it's the human's turn again
+func (s *Scene) undo() {
+	if _, _, ok := s.game.LastMove(); !ok {
+		return
+	}
+	s.game.Undo()
+	if s.game.Turn() == s.ai {
+		if _, _, ok := s.game.LastMove(); ok {
+			s.game.Undo()
+		}
+	}
+}
+
+// layout returns the size of a cell and the lower left corner of the board
+func (s *Scene) layout(win *pixelgl.Window) (float64, pixel.Vec) {
+	cols, rows := s.game.Size()
+	unit := math.Min((win.Bounds().W()-2*margin)/float64(cols), (win.Bounds().H()-3*margin)/float64(rows))
+	offset := pixel.V((win.Bounds().W()-unit*float64(cols))/2, margin)
+	return unit, offset
+}
+
+// hoveredMove returns the legal move under the mouse cursor
+func (s *Scene) hoveredMove(win *pixelgl.Window) (int, bool) {
+	unit, offset := s.layout(win)
+	pos := win.MousePosition().Sub(offset)
+	if pos.X < 0 || pos.Y < 0 {
+		return 0, false
+	}
+	return s.game.MoveAt(int(pos.X/unit), int(pos.Y/unit))
+}
+
+func (s *Scene) status() string {
+	if s.over() {
+		if s.game.Winner() == None {
+			return "Draw!"
+		}
+		return fmt.Sprintf("%s wins!", s.name(s.game.Winner()))
+	}
+	if s.thinking {
+		return "Computer is thinking..."
+	}
+	return fmt.Sprintf("%s to move", s.name(s.game.Turn()))
+}
+
+// name returns how player p is shown in the status
+func (s *Scene) name(p Player) string {
+	switch {
+	case s.ai == None:
+		return fmt.Sprintf("Player %d", p)
+	case p == s.ai:
+		return "Computer"
+	default:
+		return "You"
+	}
+}
+
+var playerColors = map[Player]pixel.RGBA{
+	First:  pixel.ToRGBA(colornames.Crimson),
+	Second: pixel.ToRGBA(colornames.Gold),
+}
+
+func (s *Scene) draw(win *pixelgl.Window) {
+	win.Clear(colornames.Darkslateblue)
+	cols, rows := s.game.Size()
+	unit, offset := s.layout(win)
+	center := func(x, y int) pixel.Vec {
+		return offset.Add(pixel.V((float64(x)+0.5)*unit, (float64(y)+0.5)*unit))
+	}
+	imd := imdraw.New(nil)
+	// highlight the cell the current player would play
+	if !s.over() && !s.thinking && s.game.Turn() != s.ai {
+		if move, ok := s.hoveredMove(win); ok {
+			// find the cell of the hovered move by trying it
+			s.game.Play(move)
+			x, y, _ := s.game.LastMove()
+			s.game.Undo()
+			imd.Color = colornames.Slateblue
+			imd.Push(center(x, y).Sub(pixel.V(unit/2, unit/2)), center(x, y).Add(pixel.V(unit/2, unit/2)))
+			imd.Rectangle(0)
+		}
+	}
+	// draw grid lines
+	imd.Color = colornames.Lightsteelblue
+	for x := 0; x <= cols; x++ {
+		imd.Push(offset.Add(pixel.V(float64(x)*unit, 0)), offset.Add(pixel.V(float64(x)*unit, float64(rows)*unit)))
+		imd.Line(2)
+	}
+	for y := 0; y <= rows; y++ {
+		imd.Push(offset.Add(pixel.V(0, float64(y)*unit)), offset.Add(pixel.V(float64(cols)*unit, float64(y)*unit)))
+		imd.Line(2)
+	}
+	// draw pieces
+	_, crosses := s.game.(*TicTacToe)
+	r := unit * 0.38
+	for x := 0; x < cols; x++ {
+		for y := 0; y < rows; y++ {
+			p := s.game.At(x, y)
+			if p == None {
+				continue
+			}
+			imd.Color = playerColors[p]
+			c := center(x, y)
+			if crosses && p == First {
+				imd.Push(c.Add(pixel.V(-r, -r)), c.Add(pixel.V(r, r)))
+				imd.Line(unit / 10)
+				imd.Push(c.Add(pixel.V(-r, r)), c.Add(pixel.V(r, -r)))
+				imd.Line(unit / 10)
+			} else if crosses {
+				imd.Push(c)
+				imd.Circle(r, unit/10)
+			} else {
+				imd.Push(c)
+				imd.Circle(r, 0)
+			}
+		}
+	}
+	// mark the last move
+	if x, y, ok := s.game.LastMove(); ok {
+		imd.Color = colornames.White
+		imd.Push(center(x, y))
+		imd.Circle(unit*0.45, 2)
+	}
+	// draw the winning line
+	if line := s.game.WinLine(); line != nil {
+		imd.Color = colornames.Lime
+		imd.Push(center(line[0][0], line[0][1]), center(line[len(line)-1][0], line[len(line)-1][1]))
+		imd.Line(unit / 8)
+	}
+	imd.Draw(win)
+
+	txt := text.New(pixel.ZV, atlas)
+	txt.Color = colornames.White
+	fmt.Fprintf(txt, "%s - %s\n", s.game.Name(), s.status())
+	txt.Color = colornames.Lightgray
+	fmt.Fprint(txt, "Click: move  U: undo  R: restart  Esc: menu")
+	txt.Draw(win, pixel.IM.Moved(pixel.V(margin, win.Bounds().H()-margin/2)))
+}
@@ -0,0 +1,105 @@
+package boardgames
+
+import "github.com/miluchen/games-in-go/games/search"
+
+const tttSize = 3
+
+// TicTacToe is played on a 3x3 board, a move is the cell index x+y*3
+type TicTacToe struct {
+	cells   [tttSize * tttSize]Player
+	history []int // moves made so far
+	winLine [][2]int
+}
+
+func NewTicTacToe() *TicTacToe {
+	return &TicTacToe{}
+}
+
+func (t *TicTacToe) Name() string {
+	return "Tic-Tac-Toe"
+}
+
+func (t *TicTacToe) Size() (int, int) {
+	return tttSize, tttSize
+}
+
+func (t *TicTacToe) At(x, y int) Player {
+	if x < 0 || x >= tttSize || y < 0 || y >= tttSize {
+		return None
+	}
+	return t.cells[x+y*tttSize]
+}
+
+func (t *TicTacToe) Turn() Player {
+	if len(t.history)%2 == 0 {
+		return First
+	}
+	return Second
+}
+
+func (t *TicTacToe) Winner() Player {
+	if t.winLine == nil {
+		return None
+	}
+	return t.Turn().Other()
+}
+
+func (t *TicTacToe) WinLine() [][2]int {
+	return t.winLine
+}
+
+func (t *TicTacToe) Moves() []int {
+	if t.winLine != nil {
+		return nil
+	}
+	var moves []int
+	// center first, then corners, then edges, which is the usual strength of the cells
+	for _, i := range []int{4, 0, 2, 6, 8, 1, 3, 5, 7} {
+		if t.cells[i] == None {
+			moves = append(moves, i)
+		}
+	}
+	return moves
+}
+
+func (t *TicTacToe) Play(move int) {
+	t.cells[move] = t.Turn()
+	t.history = append(t.history, move)
+	t.winLine = findLine(t.At, move%tttSize, move/tttSize, tttSize)
+}
+
+func (t *TicTacToe) Undo() {
+	move := t.history[len(t.history)-1]
+	t.history = t.history[:len(t.history)-1]
+	t.cells[move] = None
+	t.winLine = nil
+}
+
+// Evaluate only scores decided games, the board is small enough to be searched to the end
+func (t *TicTacToe) Evaluate() int {
+	if t.winLine != nil {
+		return -search.Win
+	}
+	return 0
+}
+
+func (t *TicTacToe) MoveAt(x, y int) (int, bool) {
+	if x < 0 || x >= tttSize || y < 0 || y >= tttSize || t.winLine != nil || t.cells[x+y*tttSize] != None {
+		return 0, false
+	}
+	return x + y*tttSize, true
+}
+
+func (t *TicTacToe) LastMove() (int, int, bool) {
+	if len(t.history) == 0 {
+		return 0, 0, false
+	}
+	move := t.history[len(t.history)-1]
+	return move % tttSize, move / tttSize, true
+}
+
+func (t *TicTacToe) Clone() Game {
+	c := *t
+	c.history = append([]int(nil), t.history...)
+	return &c
+}
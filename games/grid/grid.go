@@ -0,0 +1,167 @@
+// Package grid contains the cells and directions shared by the games played on a grid, square or hex
+package grid
+
+type Direction int
+
+const (
+	North Direction = iota
+	East
+	South
+	West
+	NorthEast // the diagonals are only used on hex grids, where they lead to neighbors
+	SouthEast
+	SouthWest
+	NorthWest
+)
+
+// Directions lists every direction of a square grid clockwise starting from North
+var Directions = []Direction{North, East, South, West}
+
+// HexDirections lists every direction of a hex grid clockwise starting from North
+var HexDirections = []Direction{North, NorthEast, SouthEast, South, SouthWest, NorthWest}
+
+// deltas are (dx, dy) per direction on a square grid, y grows towards North
+var deltas = [][]int{
+	North:     {0, 1},
+	East:      {1, 0},
+	South:     {0, -1},
+	West:      {-1, 0},
+	NorthEast: {1, 1},
+	SouthEast: {1, -1},
+	SouthWest: {-1, -1},
+	NorthWest: {-1, 1},
+}
+
+var opposites = []Direction{
+	North:     South,
+	East:      West,
+	South:     North,
+	West:      East,
+	NorthEast: SouthWest,
+	SouthEast: NorthWest,
+	SouthWest: NorthEast,
+	NorthWest: SouthEast,
+}
+
+// Delta returns how x and y change when moving one cell in direction d
+func (d Direction) Delta() (int, int) {
+	return deltas[d][0], deltas[d][1]
+}
+
+// Opposite returns the reverse of direction d
+func (d Direction) Opposite() Direction {
+	return opposites[d]
+}
+
+// Left returns the direction after turning left from d on a square grid
+func (d Direction) Left() Direction {
+	return (d + 3) % 4
+}
+
+// Right returns the direction after turning right from d on a square grid
+func (d Direction) Right() Direction {
+	return (d + 1) % 4
+}
+
+// ChangeDirection returns the new direction when action is requested while moving in dir,
+// reversing is not allowed so the direction is kept in that case
+func ChangeDirection(dir Direction, action Direction) Direction {
+	if action == dir.Opposite() {
+		return dir
+	}
+	return action
+}
+
+// Point is a cell of the grid
+type Point struct {
+	X, Y int
+}
+
+// Move returns the neighbor of p in direction d on a square grid
+func (p Point) Move(d Direction) Point {
+	dx, dy := d.Delta()
+	return Point{p.X + dx, p.Y + dy}
+}
+
+// In reports whether p is inside a grid of width x height cells
+func (p Point) In(width, height int) bool {
+	return p.X >= 0 && p.X < width && p.Y >= 0 && p.Y < height
+}
+
+// Wrap returns p brought back inside a grid of width x height cells, as if the grid's opposite
+// sides were joined
+func (p Point) Wrap(width, height int) Point {
+	return Point{(p.X%width + width) % width, (p.Y%height + height) % height}
+}
+
+// Topology is how the cells of a grid are laid out: the directions a cell has neighbors in, and
+// where they are
+type Topology interface {
+	Directions() []Direction         // the directions clockwise starting from North
+	Move(p Point, d Direction) Point // the neighbor of p in direction d, which must be one of Directions
+	Distance(a, b Point) int         // the number of steps between a and b
+}
+
+// Square is the grid of square cells, each with 4 neighbors
+var Square Topology = square{}
+
+// Hex is the grid of flat-topped hexagons, each with 6 neighbors. The cells are in columns, and the
+// odd columns are half a cell higher than the even ones, so a cell's neighbors to the east and west
+// are on its own row and the row above or below depending on the column.
+var Hex Topology = hex{}
+
+type square struct{}
+
+func (square) Directions() []Direction {
+	return Directions
+}
+
+func (square) Move(p Point, d Direction) Point {
+	return p.Move(d)
+}
+
+func (square) Distance(a, b Point) int {
+	return abs(a.X-b.X) + abs(a.Y-b.Y)
+}
+
+type hex struct{}
+
+func (hex) Directions() []Direction {
+	return HexDirections
+}
+
+func (hex) Move(p Point, d Direction) Point {
+	// an odd column is higher, so going east or west goes up a row from it
+	up := p.X & 1
+	switch d {
+	case North:
+		return Point{p.X, p.Y + 1}
+	case South:
+		return Point{p.X, p.Y - 1}
+	case NorthEast:
+		return Point{p.X + 1, p.Y + up}
+	case SouthEast:
+		return Point{p.X + 1, p.Y + up - 1}
+	case SouthWest:
+		return Point{p.X - 1, p.Y + up - 1}
+	case NorthWest:
+		return Point{p.X - 1, p.Y + up}
+	}
+	// east and west lead to no cell, a caller going there would stand still without noticing
+	panic("grid: hex cells have no neighbor to the east or west")
+}
+
+func (hex) Distance(a, b Point) int {
+	// in axial coordinates, where the column stays x and the row is counted along the slope
+	// of the columns, the hex distance has a closed form
+	dq := b.X - a.X
+	dr := (b.Y - b.X>>1) - (a.Y - a.X>>1)
+	return (abs(dq) + abs(dr) + abs(dq+dr)) / 2
+}
+
+func abs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
@@ -0,0 +1,151 @@
+// Package leaderboard is an HTTP leaderboard shared by the games, and a server implementing it over SQLite.
+//
+// The API has a single resource, /scores:
+//
+//	POST /scores                          submits a Score as JSON, answers 201 Created
+//	GET  /scores?game=snake&mode=classic&n=10   returns the best n Scores of a game and mode as a JSON array
+//
+// A Score carries an ID picked by the client, submitting the same ID twice stores it once, so a
+// client may retry a submission it isn't sure went through.
+package leaderboard
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultTop = 10  // scores returned when n isn't given
+	MaxTop     = 100 // most scores returned at once
+)
+
+// Score is one result on the leaderboard
+type Score struct {
+	ID    string    `json:"id"`   // unique id picked by the client
+	Game  string    `json:"game"` // e.g. snake
+	Mode  string    `json:"mode"` // e.g. classic
+	Name  string    `json:"name"`
+	Score int       `json:"score"`
+	Time  time.Time `json:"time"` // when the score was made
+}
+
+// Server serves the leaderboard API from a SQLite database
+type Server struct {
+	db *sql.DB
+}
+
+// NewServer creates the leaderboard table in db if needed
+func NewServer(db *sql.DB) (*Server, error) {
+	sqlStmt := `create table if not exists scores (
+		id text not null primary key,
+		game text not null,
+		mode text not null,
+		name text not null,
+		score integer not null,
+		time timestamp not null
+	);
+	create index if not exists scores_rank on scores (game, mode, score desc, time);`
+	if _, err := db.Exec(sqlStmt); err != nil {
+		return nil, err
+	}
+	return &Server{db: db}, nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/scores" {
+		http.NotFound(w, r)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		s.top(w, r)
+	case http.MethodPost:
+		s.submit(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// submit stores the score in the request body
+func (s *Server) submit(w http.ResponseWriter, r *http.Request) {
+	var score Score
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&score); err != nil {
+		http.Error(w, fmt.Sprintf("bad score: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err := validate(score); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if score.Time.IsZero() {
+		score.Time = time.Now()
+	}
+	stmt := "insert into scores(id, game, mode, name, score, time) values(?, ?, ?, ?, ?, ?) on conflict(id) do nothing"
+	if _, err := s.db.Exec(stmt, score.ID, score.Game, score.Mode, score.Name, score.Score, score.Time.UTC()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// top returns the best scores of a game and mode
+func (s *Server) top(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	game, mode := query.Get("game"), query.Get("mode")
+	if game == "" || mode == "" {
+		http.Error(w, "game and mode are required", http.StatusBadRequest)
+		return
+	}
+	n := DefaultTop
+	if query.Get("n") != "" {
+		var err error
+		if n, err = strconv.Atoi(query.Get("n")); err != nil || n < 1 {
+			http.Error(w, "n must be a positive number", http.StatusBadRequest)
+			return
+		}
+	}
+	n = min(n, MaxTop)
+	rows, err := s.db.Query(`select id, game, mode, name, score, time from scores
+		where game = ? and mode = ? order by score desc, time limit ?`, game, mode, n)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+	scores := []Score{}
+	for rows.Next() {
+		var score Score
+		if err := rows.Scan(&score.ID, &score.Game, &score.Mode, &score.Name, &score.Score, &score.Time); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		scores = append(scores, score)
+	}
+	if err := rows.Err(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(scores)
+}
+
+// validate checks a submitted score has everything the leaderboard needs
+func validate(score Score) error {
+	switch {
+	case score.ID == "":
+		return fmt.Errorf("id is required")
+	case score.Game == "" || score.Mode == "":
+		return fmt.Errorf("game and mode are required")
+	case strings.TrimSpace(score.Name) == "":
+		return fmt.Errorf("name is required")
+	case score.Score < 0:
+		return fmt.Errorf("score can't be negative")
+	}
+	return nil
+}
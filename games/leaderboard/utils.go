@@ -0,0 +1,8 @@
+package leaderboard
+
+func min(a, b int) int {
+	if a > b {
+		return b
+	}
+	return a
+}
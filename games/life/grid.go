@@ -0,0 +1,197 @@
+// grid.go contains a bit-packed grid that computes generations 64 cells at a time
+
+package life
+
+import "math/bits"
+
+type Edge int
+
+const (
+	Toroidal Edge = iota // cells on one side are neighbors of the cells on the opposite side
+	Bounded              // cells outside the grid are always dead
+)
+
+func (e Edge) String() string {
+	if e == Toroidal {
+		return "toroidal"
+	}
+	return "bounded"
+}
+
+const wordBits = 64
+
+// Grid holds cells in rows of uint64 words, bit i of word w in a row is the cell at x = w*64+i
+type Grid struct {
+	Width  int
+	Height int
+	Edge   Edge
+
+	words int      // number of words per row
+	cells []uint64 // current generation, padding bits in the last word of a row are always 0
+	next  []uint64 // buffer for the next generation
+	west  []uint64 // buffers of a row shifted by one cell, see shiftRow
+	east  []uint64
+}
+
+func NewGrid(width, height int, edge Edge) *Grid {
+	words := (width + wordBits - 1) / wordBits
+	return &Grid{
+		Width:  width,
+		Height: height,
+		Edge:   edge,
+		words:  words,
+		cells:  make([]uint64, words*height),
+		next:   make([]uint64, words*height),
+		west:   make([]uint64, words*3),
+		east:   make([]uint64, words*3),
+	}
+}
+
+// contains reports whether (x, y) is inside the grid
+func (g *Grid) contains(x, y int) bool {
+	return x >= 0 && x < g.Width && y >= 0 && y < g.Height
+}
+
+// Alive reports whether the cell at (x, y) is alive, cells outside the grid are dead
+func (g *Grid) Alive(x, y int) bool {
+	if !g.contains(x, y) {
+		return false
+	}
+	return g.cells[y*g.words+x/wordBits]&(1<<uint(x%wordBits)) != 0
+}
+
+// Set sets the cell at (x, y) alive or dead, cells outside the grid are ignored
+func (g *Grid) Set(x, y int, alive bool) {
+	if !g.contains(x, y) {
+		return
+	}
+	i := y*g.words + x/wordBits
+	if alive {
+		g.cells[i] |= 1 << uint(x%wordBits)
+	} else {
+		g.cells[i] &^= 1 << uint(x%wordBits)
+	}
+}
+
+// Clear kills every cell
+func (g *Grid) Clear() {
+	for i := range g.cells {
+		g.cells[i] = 0
+	}
+}
+
+// Population returns the number of live cells
+func (g *Grid) Population() int {
+	count := 0
+	for _, word := range g.cells {
+		count += bits.OnesCount64(word)
+	}
+	return count
+}
+
+// Each calls fn for every live cell
+func (g *Grid) Each(fn func(x, y int)) {
+	for y := 0; y < g.Height; y++ {
+		for w := 0; w < g.words; w++ {
+			word := g.cells[y*g.words+w]
+			for word != 0 {
+				i := bits.TrailingZeros64(word)
+				fn(w*wordBits+i, y)
+				word &= word - 1
+			}
+		}
+	}
+}
+
+// lastMask masks out the padding bits of the last word of a row
+func (g *Grid) lastMask() uint64 {
+	if r := g.Width % wordBits; r != 0 {
+		return 1<<uint(r) - 1
+	}
+	return ^uint64(0)
+}
+
+// row returns row y, taking the edge mode into account for rows outside the grid
+func (g *Grid) row(y int) []uint64 {
+	if y < 0 || y >= g.Height {
+		if g.Edge == Bounded {
+			return nil
+		}
+		y = (y + g.Height) % g.Height
+	}
+	return g.cells[y*g.words : (y+1)*g.words]
+}
+
+// shiftRow fills west with the left neighbor and east with the right neighbor of each cell in row,
+// i.e. bit x of west is the cell at x-1 and bit x of east is the cell at x+1
+func (g *Grid) shiftRow(row, west, east []uint64) {
+	if row == nil {
+		for w := range west {
+			west[w] = 0
+			east[w] = 0
+		}
+		return
+	}
+	last := g.words - 1
+	// cells wrapping around the left and right edges
+	var leftEdge, rightEdge uint64
+	if g.Edge == Toroidal {
+		leftEdge = row[last] >> uint((g.Width-1)%wordBits) & 1
+		rightEdge = row[0] & 1
+	}
+	for w := 0; w <= last; w++ {
+		carryIn := leftEdge
+		if w > 0 {
+			carryIn = row[w-1] >> (wordBits - 1)
+		}
+		west[w] = row[w]<<1 | carryIn
+		if w < last {
+			east[w] = row[w]>>1 | row[w+1]<<(wordBits-1)
+		} else {
+			east[w] = row[w]>>1 | rightEdge<<uint((g.Width-1)%wordBits)
+		}
+	}
+	west[last] &= g.lastMask()
+}
+
+// Step advances the grid by one generation using the B3/S23 rule
+func (g *Grid) Step() {
+	words := g.words
+	// shifted copies of the rows above, at and below the current row, rotated as y advances
+	west := [3][]uint64{g.west[:words], g.west[words : 2*words], g.west[2*words:]}
+	east := [3][]uint64{g.east[:words], g.east[words : 2*words], g.east[2*words:]}
+	g.shiftRow(g.row(-1), west[0], east[0])
+	g.shiftRow(g.row(0), west[1], east[1])
+	for y := 0; y < g.Height; y++ {
+		up, cur, down := g.row(y-1), g.row(y), g.row(y+1)
+		g.shiftRow(down, west[2], east[2])
+		for w := 0; w < words; w++ {
+			var n, s uint64
+			if up != nil {
+				n = up[w]
+			}
+			if down != nil {
+				s = down[w]
+			}
+			alive := cur[w]
+			// count the 8 neighbors with bit-sliced adders
+			s1, c1 := fullAdd(west[0][w], n, east[0][w])
+			s2, c2 := fullAdd(west[2][w], s, east[2][w])
+			s3, c3 := west[1][w]^east[1][w], west[1][w]&east[1][w]
+			ones, c4 := fullAdd(s1, s2, s3)
+			t1, d1 := fullAdd(c1, c2, c3)
+			twos, d2 := t1^c4, t1&c4
+			fours := d1 | d2 // 4 or more neighbors
+			// a cell lives with exactly 3 neighbors, or with 2 if it's alive
+			g.next[y*words+w] = twos &^ fours & (ones | alive)
+		}
+		g.next[y*words+words-1] &= g.lastMask()
+		west[0], west[1], west[2] = west[1], west[2], west[0]
+		east[0], east[1], east[2] = east[1], east[2], east[0]
+	}
+	g.cells, g.next = g.next, g.cells
+}
+
+func fullAdd(a, b, c uint64) (sum, carry uint64) {
+	return a ^ b ^ c, a&b | c&(a^b)
+}
@@ -0,0 +1,66 @@
+package life
+
+import (
+	"log"
+	"os"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/colornames"
+	"golang.org/x/image/font/basicfont"
+)
+
+const (
+	gridWidth  = 256 // width of the grid as in number of cells
+	gridHeight = 192 // height of the grid as in number of cells
+)
+
+var atlas = text.NewAtlas(basicfont.Face7x13, text.ASCII)
+
+var patternFile string // pattern loaded on start and with the L key
+
+// loadPattern reads the pattern file in RLE or plaintext format
+func loadPattern() (*Pattern, error) {
+	f, err := os.Open(patternFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParsePattern(f)
+}
+
+func run() {
+	// initialize window
+	cfg := pixelgl.WindowConfig{
+		Title:  "life",
+		Bounds: pixel.R(0, 0, 800, 600),
+		VSync:  true,
+	}
+	win, err := pixelgl.NewWindow(cfg)
+	if err != nil {
+		panic(err)
+	}
+	scene := newScene(win)
+	if patternFile != "" {
+		scene.load()
+	}
+	// game loop
+	for !win.Closed() && !scene.exit {
+		win.Clear(colornames.Black)
+		scene.update(win)
+		win.Update()
+	}
+}
+
+// Run starts the Game of Life sandbox, file is an optional RLE or plaintext pattern to start with
+func Run(file string) {
+	patternFile = file
+	if patternFile != "" {
+		if _, err := loadPattern(); err != nil {
+			log.Printf("load pattern failed: %v\n", err)
+			return
+		}
+	}
+	pixelgl.Run(run)
+}
@@ -0,0 +1,314 @@
+// pattern.go reads and writes patterns in the RLE and plaintext formats
+
+package life
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxPatternSize = 1 << 16 // max width or height of a pattern
+	rleLineLength  = 70      // max length of a line written in RLE
+	lifeRule       = "B3/S23"
+)
+
+// Pattern is a set of live cells, (0, 0) is the top left corner
+type Pattern struct {
+	Name   string
+	Width  int
+	Height int
+	Cells  [][2]int // coordinates of the live cells
+}
+
+var rleHeader = regexp.MustCompile(`^x\s*=\s*(\d+)\s*,\s*y\s*=\s*(\d+)\s*(?:,\s*rule\s*=\s*(\S+))?`)
+
+// ParsePattern reads a pattern in RLE or plaintext format, the format is detected from the content
+func ParsePattern(r io.Reader) (*Pattern, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	content := string(data)
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if rleHeader.MatchString(line) {
+			return ParseRLE(strings.NewReader(content))
+		}
+		break
+	}
+	return ParsePlaintext(strings.NewReader(content))
+}
+
+// ParseRLE reads a pattern in the run length encoded format, e.g.
+//
+//	#N Glider
+//	x = 3, y = 3, rule = B3/S23
+//	bob$2bo$3o!
+func ParseRLE(r io.Reader) (*Pattern, error) {
+	p := &Pattern{}
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	header := false
+	x, y, count := 0, 0, 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			if strings.HasPrefix(line, "#N") {
+				p.Name = strings.TrimSpace(line[2:])
+			}
+			continue
+		}
+		if !header {
+			m := rleHeader.FindStringSubmatch(line)
+			if m == nil {
+				return nil, fmt.Errorf("line %d: expected header \"x = <width>, y = <height>\"", lineNo)
+			}
+			p.Width, _ = strconv.Atoi(m[1])
+			p.Height, _ = strconv.Atoi(m[2])
+			if p.Width > maxPatternSize || p.Height > maxPatternSize {
+				return nil, fmt.Errorf("line %d: pattern is %dx%d, the max size is %dx%d", lineNo, p.Width, p.Height, maxPatternSize, maxPatternSize)
+			}
+			if m[3] != "" && !isLifeRule(m[3]) {
+				return nil, fmt.Errorf("line %d: unsupported rule %s, only %s is supported", lineNo, m[3], lifeRule)
+			}
+			header = true
+			continue
+		}
+		for _, c := range line {
+			switch {
+			case c >= '0' && c <= '9':
+				count = count*10 + int(c-'0')
+				if count > maxPatternSize {
+					return nil, fmt.Errorf("line %d: run count is larger than %d", lineNo, maxPatternSize)
+				}
+				continue
+			case c == '!':
+				return p, p.validate()
+			case c == ' ' || c == '\t':
+				continue
+			}
+			if count == 0 {
+				count = 1
+			}
+			switch c {
+			case '$':
+				y += count
+				x = 0
+			case 'b', '.':
+				x += count
+			default:
+				// 'o' and any other state of multi-state rules are alive
+				if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+					return nil, fmt.Errorf("line %d: invalid character %q", lineNo, c)
+				}
+				for i := 0; i < count; i++ {
+					p.Cells = append(p.Cells, [2]int{x + i, y})
+				}
+				x += count
+			}
+			count = 0
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !header {
+		return nil, fmt.Errorf("missing header")
+	}
+	return p, p.validate()
+}
+
+// isLifeRule reports whether rule is B3/S23 in either B/S or S/B notation
+func isLifeRule(rule string) bool {
+	rule = strings.ToUpper(rule)
+	return rule == lifeRule || rule == "S23/B3" || rule == "23/3"
+}
+
+// ParsePlaintext reads a pattern in the plaintext format, '!' starts a comment line,
+// 'O' is a live cell and '.' is a dead cell
+func ParsePlaintext(r io.Reader) (*Pattern, error) {
+	p := &Pattern{}
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	y := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if strings.HasPrefix(line, "!") {
+			if strings.HasPrefix(line, "!Name:") {
+				p.Name = strings.TrimSpace(line[len("!Name:"):])
+			}
+			continue
+		}
+		for x, c := range line {
+			switch c {
+			case 'O', 'o', '*':
+				p.Cells = append(p.Cells, [2]int{x, y})
+			case '.':
+			default:
+				return nil, fmt.Errorf("line %d: invalid character %q", lineNo, c)
+			}
+		}
+		p.Width = max(p.Width, len(line))
+		y++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	p.Height = y
+	return p, p.validate()
+}
+
+// validate checks that the pattern is not empty and every cell is inside its bounds
+func (p *Pattern) validate() error {
+	if len(p.Cells) == 0 {
+		return fmt.Errorf("pattern has no live cells")
+	}
+	if p.Width > maxPatternSize || p.Height > maxPatternSize {
+		return fmt.Errorf("pattern is %dx%d, the max size is %dx%d", p.Width, p.Height, maxPatternSize, maxPatternSize)
+	}
+	for _, cell := range p.Cells {
+		if cell[0] >= p.Width || cell[1] >= p.Height {
+			return fmt.Errorf("cell (%d, %d) is outside the %dx%d pattern", cell[0], cell[1], p.Width, p.Height)
+		}
+	}
+	return nil
+}
+
+// rows returns the pattern as rows of booleans
+func (p *Pattern) rows() [][]bool {
+	rows := make([][]bool, p.Height)
+	for y := range rows {
+		rows[y] = make([]bool, p.Width)
+	}
+	for _, cell := range p.Cells {
+		rows[cell[1]][cell[0]] = true
+	}
+	return rows
+}
+
+// WriteRLE writes the pattern in the run length encoded format
+func (p *Pattern) WriteRLE(w io.Writer) error {
+	var sb strings.Builder
+	if p.Name != "" {
+		fmt.Fprintf(&sb, "#N %s\n", p.Name)
+	}
+	fmt.Fprintf(&sb, "x = %d, y = %d, rule = %s\n", p.Width, p.Height, lifeRule)
+
+	var tokens []string
+	emit := func(count int, tag byte) {
+		if count == 0 {
+			return
+		}
+		if count == 1 {
+			tokens = append(tokens, string(tag))
+		} else {
+			tokens = append(tokens, fmt.Sprintf("%d%c", count, tag))
+		}
+	}
+	lastY := 0 // last row with live cells written
+	for y, row := range p.rows() {
+		// trailing dead cells of a row are omitted
+		end := len(row)
+		for end > 0 && !row[end-1] {
+			end--
+		}
+		if end == 0 {
+			continue
+		}
+		emit(y-lastY, '$')
+		lastY = y
+		for x := 0; x < end; {
+			run := 1
+			for x+run < end && row[x+run] == row[x] {
+				run++
+			}
+			tag := byte('b')
+			if row[x] {
+				tag = 'o'
+			}
+			emit(run, tag)
+			x += run
+		}
+	}
+	tokens = append(tokens, "!")
+	// wrap lines
+	lineLen := 0
+	for _, token := range tokens {
+		if lineLen+len(token) > rleLineLength {
+			sb.WriteString("\n")
+			lineLen = 0
+		}
+		sb.WriteString(token)
+		lineLen += len(token)
+	}
+	sb.WriteString("\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// WritePlaintext writes the pattern in the plaintext format
+func (p *Pattern) WritePlaintext(w io.Writer) error {
+	var sb strings.Builder
+	if p.Name != "" {
+		fmt.Fprintf(&sb, "!Name: %s\n", p.Name)
+	}
+	for _, row := range p.rows() {
+		end := len(row)
+		for end > 0 && !row[end-1] {
+			end--
+		}
+		for _, alive := range row[:end] {
+			if alive {
+				sb.WriteByte('O')
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// Pattern returns the live cells of the grid cropped to their bounding box, or nil if there are none
+func (g *Grid) Pattern() *Pattern {
+	minX, minY, maxX, maxY := g.Width, g.Height, -1, -1
+	g.Each(func(x, y int) {
+		minX, minY = min(minX, x), min(minY, y)
+		maxX, maxY = max(maxX, x), max(maxY, y)
+	})
+	if maxX < 0 {
+		return nil
+	}
+	p := &Pattern{Width: maxX - minX + 1, Height: maxY - minY + 1}
+	g.Each(func(x, y int) {
+		p.Cells = append(p.Cells, [2]int{x - minX, y - minY})
+	})
+	return p
+}
+
+// Place sets the cells of pattern p alive with its top left corner at (x, y),
+// cells falling outside the grid wrap around on a toroidal grid and are dropped otherwise
+func (g *Grid) Place(p *Pattern, x, y int) {
+	for _, cell := range p.Cells {
+		cx, cy := x+cell[0], y+cell[1]
+		if g.Edge == Toroidal {
+			cx = (cx%g.Width + g.Width) % g.Width
+			cy = (cy%g.Height + g.Height) % g.Height
+		}
+		g.Set(cx, cy, true)
+	}
+}
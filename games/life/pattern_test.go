@@ -0,0 +1,76 @@
+package life
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePattern(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  *Pattern
+	}{
+		{"rle", "#N Glider\n#C a comment\nx = 3, y = 3, rule = B3/S23\nbob$2bo$3o!\n",
+			&Pattern{Name: "Glider", Width: 3, Height: 3, Cells: [][2]int{{1, 0}, {2, 1}, {0, 2}, {1, 2}, {2, 2}}}},
+		{"rle without rule", "x = 2, y = 1\n2o!", &Pattern{Width: 2, Height: 1, Cells: [][2]int{{0, 0}, {1, 0}}}},
+		{"rle s/b rule", "x=1,y=1,rule=s23/b3\no!", &Pattern{Width: 1, Height: 1, Cells: [][2]int{{0, 0}}}},
+		{"rle run counts", "x = 14, y = 1\n12bo b!", &Pattern{Width: 14, Height: 1, Cells: [][2]int{{12, 0}}}},
+		{"rle blank rows", "x = 2, y = 4\no3$bo!", &Pattern{Width: 2, Height: 4, Cells: [][2]int{{0, 0}, {1, 3}}}},
+		{"rle across lines", "x = 3, y = 2\n2o$\n3o!", &Pattern{Width: 3, Height: 2, Cells: [][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}, {2, 1}}}},
+		{"rle ends at bang", "x = 1, y = 1\no!\nanything after the end $#!", &Pattern{Width: 1, Height: 1, Cells: [][2]int{{0, 0}}}},
+		{"rle without bang", "x = 1, y = 2\n$o", &Pattern{Width: 1, Height: 2, Cells: [][2]int{{0, 1}}}},
+		{"plaintext", "!Name: Blinker\n!\n.O.\n.O\n.O*\n",
+			&Pattern{Name: "Blinker", Width: 3, Height: 3, Cells: [][2]int{{1, 0}, {1, 1}, {1, 2}, {2, 2}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParsePattern(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(p, tt.want) {
+				t.Errorf("got %+v, want %+v", p, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePatternErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		parse func(string) (*Pattern, error)
+		input string
+		err   string
+	}{
+		{"no header", parseRLE, "bob$2bo$3o!", "line 1: expected header \"x = <width>, y = <height>\""},
+		{"header without y", parseRLE, "#N Glider\nx = 3\nbob$2bo$3o!", "line 2: expected header"},
+		{"only comments", parseRLE, "#N Nothing\n#C here\n", "missing header"},
+		{"other rule", parseRLE, "x = 3, y = 3, rule = B36/S23\n3o!", "line 1: unsupported rule B36/S23, only B3/S23 is supported"},
+		{"too large", parseRLE, "x = 70000, y = 1\no!", "line 1: pattern is 70000x1, the max size is 65536x65536"},
+		{"long run", parseRLE, "x = 3, y = 3\n70000o!", "line 2: run count is larger than 65536"},
+		{"bad character", parseRLE, "x = 3, y = 1\no#o!", "line 2: invalid character '#'"},
+		{"outside the header", parseRLE, "x = 2, y = 1\n3o!", "cell (2, 0) is outside the 2x1 pattern"},
+		{"rows past the header", parseRLE, "x = 1, y = 1\no$o!", "cell (0, 1) is outside the 1x1 pattern"},
+		{"no live cells", parseRLE, "x = 2, y = 1\n2b!", "pattern has no live cells"},
+		{"plaintext character", parsePlaintext, "!Name: x\nO.O\nOxO\n", "line 3: invalid character 'x'"},
+		{"plaintext empty", parsePlaintext, "!Name: x\n...\n", "pattern has no live cells"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.parse(tt.input)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func parseRLE(s string) (*Pattern, error) {
+	return ParseRLE(strings.NewReader(s))
+}
+
+func parsePlaintext(s string) (*Pattern, error) {
+	return ParsePlaintext(strings.NewReader(s))
+}
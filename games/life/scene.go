@@ -0,0 +1,264 @@
+package life
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/colornames"
+)
+
+const (
+	minZoom       = 1  // min size of a cell in pixels
+	maxZoom       = 32 // max size of a cell in pixels
+	panSpeed      = 20 // number of pixels the view moves per frame with arrow keys
+	maxStepsFrame = 64 // max generations computed in a single frame
+	rleFile       = "life.rle"
+	plaintextFile = "life.cells"
+)
+
+// generations per second for each speed setting
+var speeds = []int{1, 2, 5, 10, 20, 30, 60, 120, 240, 480, 960}
+
+type Scene struct {
+	grid       *Grid
+	generation int       // number of generations since the grid was last edited
+	running    bool      // whether the simulation is running
+	speed      int       // index in speeds
+	lastStep   time.Time // timestamp of the last generation
+	exit       bool      // whether the user wants to leave
+
+	zoom   float64   // size of a cell in pixels
+	center pixel.Vec // grid coordinates shown in the center of the window
+
+	painting bool   // whether a mouse button is held down on the grid
+	lastCell [2]int // cell painted in the previous frame, used to paint continuous lines
+	message  string // result of the last import or export
+}
+
+func newScene(win *pixelgl.Window) *Scene {
+	zoom := math.Floor(math.Min(win.Bounds().W()/gridWidth, win.Bounds().H()/gridHeight))
+	return &Scene{
+		grid:   NewGrid(gridWidth, gridHeight, Toroidal),
+		speed:  3,
+		zoom:   math.Max(minZoom, zoom),
+		center: pixel.V(gridWidth/2, gridHeight/2),
+	}
+}
+
+func (s *Scene) update(win *pixelgl.Window) {
+	s.handleInput(win)
+	if s.running {
+		// compute the generations due since the last one, so the speed does not depend on the frame rate
+		interval := time.Second / time.Duration(speeds[s.speed])
+		for i := 0; i < maxStepsFrame && time.Since(s.lastStep) >= interval; i++ {
+			s.step()
+			s.lastStep = s.lastStep.Add(interval)
+		}
+		if time.Since(s.lastStep) >= interval {
+			// the grid can't keep up, drop the generations that are behind
+			s.lastStep = time.Now()
+		}
+	}
+	s.draw(win)
+}
+
+func (s *Scene) step() {
+	s.grid.Step()
+	s.generation++
+}
+
+func (s *Scene) handleInput(win *pixelgl.Window) {
+	switch {
+	case win.JustPressed(pixelgl.KeyEscape):
+		s.exit = true
+	case win.JustPressed(pixelgl.KeySpace):
+		s.running = !s.running
+		s.lastStep = time.Now()
+	case win.JustPressed(pixelgl.KeyN) || win.Repeated(pixelgl.KeyN):
+		if !s.running {
+			s.step()
+		}
+	case win.JustPressed(pixelgl.KeyEqual) || win.JustPressed(pixelgl.KeyKPAdd):
+		s.speed = min(len(speeds)-1, s.speed+1)
+	case win.JustPressed(pixelgl.KeyMinus) || win.JustPressed(pixelgl.KeyKPSubtract):
+		s.speed = max(0, s.speed-1)
+	case win.JustPressed(pixelgl.KeyT):
+		if s.grid.Edge == Toroidal {
+			s.grid.Edge = Bounded
+		} else {
+			s.grid.Edge = Toroidal
+		}
+	case win.JustPressed(pixelgl.KeyC):
+		s.grid.Clear()
+		s.generation = 0
+	case win.JustPressed(pixelgl.KeyR):
+		s.randomize()
+	case win.JustPressed(pixelgl.KeyL):
+		s.load()
+	case win.JustPressed(pixelgl.KeyE):
+		s.export(rleFile, (*Pattern).WriteRLE)
+	case win.JustPressed(pixelgl.KeyP):
+		s.export(plaintextFile, (*Pattern).WritePlaintext)
+	}
+	// pan with arrow keys and zoom with the mouse wheel
+	if win.Pressed(pixelgl.KeyLeft) {
+		s.center.X -= panSpeed / s.zoom
+	}
+	if win.Pressed(pixelgl.KeyRight) {
+		s.center.X += panSpeed / s.zoom
+	}
+	if win.Pressed(pixelgl.KeyUp) {
+		s.center.Y -= panSpeed / s.zoom
+	}
+	if win.Pressed(pixelgl.KeyDown) {
+		s.center.Y += panSpeed / s.zoom
+	}
+	if scroll := win.MouseScroll().Y; scroll != 0 {
+		s.zoom = math.Max(minZoom, math.Min(maxZoom, s.zoom*math.Pow(1.25, scroll)))
+	}
+	s.paint(win)
+}
+
+// paint sets cells alive with the left mouse button and dead with the right one
+func (s *Scene) paint(win *pixelgl.Window) {
+	left, right := win.Pressed(pixelgl.MouseButtonLeft), win.Pressed(pixelgl.MouseButtonRight)
+	if !left && !right {
+		s.painting = false
+		return
+	}
+	cell := s.cellAt(win, win.MousePosition())
+	from := cell
+	if s.painting {
+		from = s.lastCell
+	}
+	// draw a line from the previous cell so fast mouse moves don't leave gaps
+	steps := max(abs(cell[0]-from[0]), abs(cell[1]-from[1]))
+	for i := 0; i <= steps; i++ {
+		t := 0.0
+		if steps > 0 {
+			t = float64(i) / float64(steps)
+		}
+		x := from[0] + int(math.Round(t*float64(cell[0]-from[0])))
+		y := from[1] + int(math.Round(t*float64(cell[1]-from[1])))
+		s.grid.Set(x, y, left)
+	}
+	s.painting = true
+	s.lastCell = cell
+	s.generation = 0
+}
+
+func (s *Scene) randomize() {
+	s.grid.Clear()
+	for y := 0; y < s.grid.Height; y++ {
+		for x := 0; x < s.grid.Width; x++ {
+			s.grid.Set(x, y, rand.Intn(4) == 0)
+		}
+	}
+	s.generation = 0
+}
+
+// load replaces the grid with the pattern file, centered
+func (s *Scene) load() {
+	if patternFile == "" {
+		s.message = "no pattern file given, use -pattern"
+		return
+	}
+	p, err := loadPattern()
+	if err != nil {
+		s.message = fmt.Sprintf("load failed: %v", err)
+		log.Printf("load pattern failed: %v\n", err)
+		return
+	}
+	if p.Width > s.grid.Width || p.Height > s.grid.Height {
+		// grow the grid so the whole pattern fits with some room around it
+		s.grid = NewGrid(max(s.grid.Width, p.Width*2), max(s.grid.Height, p.Height*2), s.grid.Edge)
+		s.center = pixel.V(float64(s.grid.Width)/2, float64(s.grid.Height)/2)
+	}
+	s.grid.Clear()
+	s.grid.Place(p, (s.grid.Width-p.Width)/2, (s.grid.Height-p.Height)/2)
+	s.generation = 0
+	s.message = fmt.Sprintf("loaded %s", patternFile)
+}
+
+// export writes the live cells to file with write
+func (s *Scene) export(file string, write func(*Pattern, io.Writer) error) {
+	p := s.grid.Pattern()
+	if p == nil {
+		s.message = "nothing to export"
+		return
+	}
+	f, err := os.Create(file)
+	if err == nil {
+		err = write(p, f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		s.message = fmt.Sprintf("export failed: %v", err)
+		log.Printf("export pattern failed: %v\n", err)
+		return
+	}
+	s.message = fmt.Sprintf("exported to %s", file)
+}
+
+// cellAt returns the grid cell under the screen position pos
+func (s *Scene) cellAt(win *pixelgl.Window, pos pixel.Vec) [2]int {
+	center := win.Bounds().Center()
+	x := s.center.X + (pos.X-center.X)/s.zoom
+	y := s.center.Y - (pos.Y-center.Y)/s.zoom
+	return [2]int{int(math.Floor(x)), int(math.Floor(y))}
+}
+
+// screenPos returns the screen position of the grid coordinates (x, y)
+func (s *Scene) screenPos(win *pixelgl.Window, x, y float64) pixel.Vec {
+	center := win.Bounds().Center()
+	return pixel.V((x-s.center.X)*s.zoom+center.X, center.Y-(y-s.center.Y)*s.zoom)
+}
+
+func (s *Scene) draw(win *pixelgl.Window) {
+	imd := imdraw.New(nil)
+	// draw the grid area, bounded grids get a visible border
+	imd.Color = colornames.Midnightblue
+	if s.grid.Edge == Bounded {
+		imd.Color = colornames.Darkred
+	}
+	border := s.screenPos(win, -0.5, -0.5)
+	imd.Push(border, s.screenPos(win, float64(s.grid.Width)+0.5, float64(s.grid.Height)+0.5))
+	imd.Rectangle(0)
+	imd.Color = colornames.Black
+	imd.Push(s.screenPos(win, 0, 0), s.screenPos(win, float64(s.grid.Width), float64(s.grid.Height)))
+	imd.Rectangle(0)
+	// draw visible live cells
+	topLeft := s.cellAt(win, pixel.V(0, win.Bounds().H()))
+	bottomRight := s.cellAt(win, pixel.V(win.Bounds().W(), 0))
+	imd.Color = colornames.Limegreen
+	s.grid.Each(func(x, y int) {
+		if x < topLeft[0] || x > bottomRight[0] || y < topLeft[1] || y > bottomRight[1] {
+			return
+		}
+		imd.Push(s.screenPos(win, float64(x), float64(y+1)), s.screenPos(win, float64(x+1), float64(y)))
+		imd.Rectangle(0)
+	})
+	imd.Draw(win)
+
+	// draw status text
+	state := "paused"
+	if s.running {
+		state = "running"
+	}
+	txt := text.New(pixel.V(10, 10), atlas)
+	txt.Color = colornames.White
+	fmt.Fprintf(txt, "Gen %d  Pop %d  %d gen/s  %s  %s  %s\n", s.generation, s.grid.Population(), speeds[s.speed], s.grid.Edge, state, s.message)
+	fmt.Fprint(txt, "Space: play/pause  N: step  +/-: speed  T: edges  C: clear  R: random  L: load  E/P: export RLE/plaintext")
+	txt.Draw(win, pixel.IM.Moved(pixel.V(0, txt.LineHeight)))
+}
@@ -0,0 +1,63 @@
+package reversi
+
+import (
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/colornames"
+	"golang.org/x/image/font/basicfont"
+)
+
+type GameState int
+
+const (
+	InGame GameState = iota
+	Exit
+)
+
+var atlas = text.NewAtlas(basicfont.Face7x13, text.ASCII)
+
+var gameState GameState
+var currentScene *Scene
+var setupMenu *SetupMenu
+
+// startGame starts a game with the setup chosen in the setup menu
+func startGame() {
+	currentScene = newScene(setupMenu.setup, NewGame())
+}
+
+// backToMenu leaves the current game and shows the setup menu
+func backToMenu() {
+	currentScene = nil
+}
+
+func run() {
+	gameState = InGame
+	currentScene = nil
+	setupMenu = newSetupMenu()
+	// initialize window
+	cfg := pixelgl.WindowConfig{
+		Title:  "reversi",
+		Bounds: pixel.R(0, 0, 560, 620),
+		VSync:  true,
+	}
+	win, err := pixelgl.NewWindow(cfg)
+	if err != nil {
+		panic(err)
+	}
+	// game loop
+	for !win.Closed() && gameState != Exit {
+		win.Clear(colornames.Black)
+		if currentScene != nil {
+			currentScene.update(win)
+		} else {
+			setupMenu.update(win)
+		}
+		win.Update()
+	}
+}
+
+// Run starts reversi for two humans or a human against the computer
+func Run() {
+	pixelgl.Run(run)
+}
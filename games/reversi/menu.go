@@ -0,0 +1,89 @@
+package reversi
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/colornames"
+)
+
+// strengths of the AI as the number of moves it searches ahead
+var strengths = []struct {
+	name  string
+	depth int
+}{
+	{"Beginner", 1},
+	{"Easy", 2},
+	{"Medium", 4},
+	{"Hard", 6},
+	{"Expert", 8},
+}
+
+const defaultStrength = 2
+
+// SetupMenu chooses the opponent and the strength of the AI, or loads a saved game
+type SetupMenu struct {
+	setup   Setup
+	message string // result of the last load
+}
+
+func newSetupMenu() *SetupMenu {
+	return &SetupMenu{setup: Setup{computer: White, strength: defaultStrength}}
+}
+
+func (m *SetupMenu) update(win *pixelgl.Window) {
+	switch {
+	case win.JustPressed(pixelgl.KeyEscape):
+		gameState = Exit
+		return
+	case win.JustPressed(pixelgl.KeyEnter):
+		startGame()
+		return
+	case win.JustPressed(pixelgl.KeyL):
+		setup, g, err := loadGame(saveFile)
+		if err != nil {
+			m.message = fmt.Sprintf("load failed: %v", err)
+			log.Printf("load game failed: %v\n", err)
+			break
+		}
+		m.setup = setup
+		currentScene = newScene(setup, g)
+		return
+	case win.JustPressed(pixelgl.KeyO):
+		// cycle computer as white, computer as black, two humans
+		switch m.setup.computer {
+		case White:
+			m.setup.computer = Black
+		case Black:
+			m.setup.computer = None
+		default:
+			m.setup.computer = White
+		}
+	case win.JustPressed(pixelgl.KeyEqual) || win.JustPressed(pixelgl.KeyKPAdd):
+		m.setup.strength = min(len(strengths)-1, m.setup.strength+1)
+	case win.JustPressed(pixelgl.KeyMinus) || win.JustPressed(pixelgl.KeyKPSubtract):
+		m.setup.strength = max(0, m.setup.strength-1)
+	}
+	m.draw(win)
+}
+
+func (m *SetupMenu) draw(win *pixelgl.Window) {
+	win.Clear(colornames.Gray)
+	txt := text.New(pixel.ZV, atlas)
+	txt.Color = colornames.Green
+	fmt.Fprint(txt, "Reversi\n\n")
+	txt.Color = colornames.White
+	fmt.Fprintf(txt, "O  Black:    %s\n", m.setup.describe(Black))
+	fmt.Fprintf(txt, "   White:    %s\n", m.setup.describe(White))
+	if m.setup.computer != None {
+		fmt.Fprintf(txt, "+- Strength: %s\n", strengths[m.setup.strength].name)
+	}
+	txt.Color = colornames.Lightgray
+	fmt.Fprintf(txt, "\nEnter: play  L: load %s  Esc: exit\n", saveFile)
+	txt.Color = colornames.Red
+	fmt.Fprint(txt, m.message)
+	txt.Draw(win, pixel.IM.Scaled(pixel.ZV, 1.5).Moved(pixel.V(40, win.Bounds().H()-80)))
+}
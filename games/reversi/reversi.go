@@ -0,0 +1,314 @@
+package reversi
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+
+	"github.com/miluchen/games-in-go/games/search"
+)
+
+type Player int
+
+const (
+	None Player = iota
+	Black
+	White
+)
+
+func (p Player) Other() Player {
+	return 3 - p
+}
+
+func (p Player) String() string {
+	switch p {
+	case Black:
+		return "Black"
+	case White:
+		return "White"
+	}
+	return "None"
+}
+
+const (
+	Size = 8  // number of rows and columns
+	Pass = -1 // move of a player that has no legal move
+
+	notAFile uint64 = 0xfefefefefefefefe // every cell but column a
+	notHFile uint64 = 0x7f7f7f7f7f7f7f7f // every cell but column h
+)
+
+// positional weights of the cells, corners are stable and the cells next to them give corners away
+var weights = [Size * Size]int{
+	100, -20, 10, 5, 5, 10, -20, 100,
+	-20, -50, -2, -2, -2, -2, -50, -20,
+	10, -2, 1, 1, 1, 1, -2, 10,
+	5, -2, 1, 0, 0, 1, -2, 5,
+	5, -2, 1, 0, 0, 1, -2, 5,
+	10, -2, 1, 1, 1, 1, -2, 10,
+	-20, -50, -2, -2, -2, -2, -50, -20,
+	100, -20, 10, 5, 5, 10, -20, 100,
+}
+
+const mobilityWeight = 5 // score of each legal move more than the opponent
+
+// shifts move every cell of a bitboard one step in each of the 8 directions
+var shifts = []func(uint64) uint64{
+	func(b uint64) uint64 { return b << 1 & notAFile }, // east
+	func(b uint64) uint64 { return b >> 1 & notHFile }, // west
+	func(b uint64) uint64 { return b << 8 },            // south
+	func(b uint64) uint64 { return b >> 8 },            // north
+	func(b uint64) uint64 { return b << 9 & notAFile }, // south east
+	func(b uint64) uint64 { return b << 7 & notHFile }, // south west
+	func(b uint64) uint64 { return b >> 7 & notAFile }, // north east
+	func(b uint64) uint64 { return b >> 9 & notHFile }, // north west
+}
+
+type turn struct {
+	move    int    // cell index or Pass
+	flipped uint64 // discs flipped by the move
+}
+
+// Game is a game of reversi on bitboards, bit i is the cell at column i%8 and row i/8, row 0 is row 1
+type Game struct {
+	discs   [3]uint64 // discs of Black and White, index None is not used
+	toMove  Player
+	history []turn
+}
+
+func NewGame() *Game {
+	g := &Game{toMove: Black}
+	// d4 and e5 are white, d5 and e4 are black
+	g.discs[White] = 1<<index(3, 3) | 1<<index(4, 4)
+	g.discs[Black] = 1<<index(3, 4) | 1<<index(4, 3)
+	return g
+}
+
+func index(x, y int) int {
+	return y*Size + x
+}
+
+// At returns the owner of the disc at column x and row y
+func (g *Game) At(x, y int) Player {
+	bit := uint64(1) << index(x, y)
+	switch {
+	case g.discs[Black]&bit != 0:
+		return Black
+	case g.discs[White]&bit != 0:
+		return White
+	}
+	return None
+}
+
+// Turn returns the player to move
+func (g *Game) Turn() Player {
+	return g.toMove
+}
+
+// Count returns the number of discs of p
+func (g *Game) Count(p Player) int {
+	return bits.OnesCount64(g.discs[p])
+}
+
+// legal returns the bitboard of the legal moves of p
+func (g *Game) legal(p Player) uint64 {
+	own, opp := g.discs[p], g.discs[p.Other()]
+	empty := ^(own | opp)
+	var moves uint64
+	for _, shift := range shifts {
+		// runs of opponent discs next to own discs, the cell after a run is a move
+		t := shift(own) & opp
+		for i := 0; i < Size-3; i++ {
+			t |= shift(t) & opp
+		}
+		moves |= shift(t) & empty
+	}
+	return moves
+}
+
+// flips returns the discs flipped if p plays on cell
+func (g *Game) flips(p Player, cell int) uint64 {
+	own, opp := g.discs[p], g.discs[p.Other()]
+	var flipped uint64
+	for _, shift := range shifts {
+		var run uint64
+		x := shift(1 << uint(cell))
+		for x&opp != 0 {
+			run |= x
+			x = shift(x)
+		}
+		if x&own != 0 {
+			flipped |= run
+		}
+	}
+	return flipped
+}
+
+// Legal reports whether the player to move can play on cell
+func (g *Game) Legal(cell int) bool {
+	return cell >= 0 && cell < Size*Size && g.legal(g.toMove)&(1<<uint(cell)) != 0
+}
+
+// Over reports whether neither player can move
+func (g *Game) Over() bool {
+	return g.legal(Black) == 0 && g.legal(White) == 0
+}
+
+// Moves returns the legal moves, best cells first, [Pass] if only the opponent can move,
+// and nothing if the game is over
+func (g *Game) Moves() []int {
+	legal := g.legal(g.toMove)
+	if legal == 0 {
+		if g.legal(g.toMove.Other()) == 0 {
+			return nil
+		}
+		return []int{Pass}
+	}
+	moves := make([]int, 0, bits.OnesCount64(legal))
+	for legal != 0 {
+		cell := bits.TrailingZeros64(legal)
+		legal &= legal - 1
+		// insert by weight so corners are searched first
+		i := len(moves)
+		moves = append(moves, cell)
+		for i > 0 && weights[moves[i-1]] < weights[cell] {
+			moves[i] = moves[i-1]
+			i--
+		}
+		moves[i] = cell
+	}
+	return moves
+}
+
+func (g *Game) Play(move int) {
+	t := turn{move: move}
+	if move != Pass {
+		t.flipped = g.flips(g.toMove, move)
+		g.discs[g.toMove] |= t.flipped | 1<<uint(move)
+		g.discs[g.toMove.Other()] &^= t.flipped
+	}
+	g.history = append(g.history, t)
+	g.toMove = g.toMove.Other()
+}
+
+func (g *Game) Undo() {
+	t := g.history[len(g.history)-1]
+	g.history = g.history[:len(g.history)-1]
+	g.toMove = g.toMove.Other()
+	if t.move != Pass {
+		g.discs[g.toMove] &^= t.flipped | 1<<uint(t.move)
+		g.discs[g.toMove.Other()] |= t.flipped
+	}
+}
+
+// Evaluate scores positional weights and mobility from the view of the player to move,
+// finished games are scored by who has more discs
+func (g *Game) Evaluate() int {
+	me, opp := g.toMove, g.toMove.Other()
+	myMoves, oppMoves := bits.OnesCount64(g.legal(me)), bits.OnesCount64(g.legal(opp))
+	if myMoves == 0 && oppMoves == 0 {
+		diff := g.Count(me) - g.Count(opp)
+		switch {
+		case diff > 0:
+			return search.Win
+		case diff < 0:
+			return -search.Win
+		}
+		return 0
+	}
+	score := mobilityWeight * (myMoves - oppMoves)
+	for cell, w := range weights {
+		bit := uint64(1) << uint(cell)
+		if g.discs[me]&bit != 0 {
+			score += w
+		} else if g.discs[opp]&bit != 0 {
+			score -= w
+		}
+	}
+	return score
+}
+
+// LastMove returns the last move that placed a disc
+func (g *Game) LastMove() (int, bool) {
+	for i := len(g.history) - 1; i >= 0; i-- {
+		if g.history[i].move != Pass {
+			return g.history[i].move, true
+		}
+	}
+	return 0, false
+}
+
+// LastPassed reports whether the previous player passed
+func (g *Game) LastPassed() bool {
+	return len(g.history) > 0 && g.history[len(g.history)-1].move == Pass
+}
+
+// Winner returns the player with more discs once the game is over, None otherwise or for a draw
+func (g *Game) Winner() Player {
+	if !g.Over() {
+		return None
+	}
+	switch b, w := g.Count(Black), g.Count(White); {
+	case b > w:
+		return Black
+	case w > b:
+		return White
+	}
+	return None
+}
+
+// Clone returns a copy of the game that can be searched while this one is drawn
+func (g *Game) Clone() *Game {
+	c := *g
+	c.history = append([]turn(nil), g.history...)
+	return &c
+}
+
+// CellName returns the cell in algebraic notation, e.g. "f5"
+func CellName(cell int) string {
+	return fmt.Sprintf("%c%d", 'a'+cell%Size, cell/Size+1)
+}
+
+// ParseCell parses a cell in algebraic notation
+func ParseCell(name string) (int, error) {
+	name = strings.ToLower(name)
+	if len(name) != 2 || name[0] < 'a' || name[0] >= 'a'+Size || name[1] < '1' || name[1] >= '1'+Size {
+		return 0, fmt.Errorf("invalid cell %q", name)
+	}
+	return index(int(name[0]-'a'), int(name[1]-'1')), nil
+}
+
+// Transcript returns the moves in the standard move list notation, e.g. "f5d6c3",
+// passes are not written as they are implied by the position
+func (g *Game) Transcript() string {
+	var sb strings.Builder
+	for _, t := range g.history {
+		if t.move != Pass {
+			sb.WriteString(CellName(t.move))
+		}
+	}
+	return sb.String()
+}
+
+// ParseTranscript replays a move list from the start position, passing whenever the player to move has to
+func ParseTranscript(transcript string) (*Game, error) {
+	g := NewGame()
+	moves := strings.Join(strings.Fields(transcript), "")
+	if len(moves)%2 != 0 {
+		return nil, fmt.Errorf("transcript has an odd number of characters")
+	}
+	for i := 0; i < len(moves); i += 2 {
+		cell, err := ParseCell(moves[i : i+2])
+		if err != nil {
+			return nil, fmt.Errorf("move %d: %v", i/2+1, err)
+		}
+		if ms := g.Moves(); len(ms) == 1 && ms[0] == Pass {
+			g.Play(Pass)
+		}
+		if !g.Legal(cell) {
+			return nil, fmt.Errorf("move %d: %s is not legal for %s", i/2+1, moves[i:i+2], g.toMove)
+		}
+		g.Play(cell)
+	}
+	return g, nil
+}
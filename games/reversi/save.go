@@ -0,0 +1,80 @@
+// save.go saves and loads games as a transcript with a header of the players
+
+package reversi
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const saveFile = "reversi.txt"
+
+// Setup is who plays each color
+type Setup struct {
+	computer Player // color played by the computer, None if both are human
+	strength int    // index in strengths
+}
+
+// describe returns who plays p, e.g. "Computer (Hard)"
+func (s Setup) describe(p Player) string {
+	if p == s.computer {
+		return fmt.Sprintf("Computer (%s)", strengths[s.strength].name)
+	}
+	return "Human"
+}
+
+// saveGame writes the players, the result so far and the transcript to file
+func saveGame(file string, setup Setup, g *Game) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Black: %s\n", setup.describe(Black))
+	fmt.Fprintf(&sb, "# White: %s\n", setup.describe(White))
+	fmt.Fprintf(&sb, "# Score: %d-%d\n", g.Count(Black), g.Count(White))
+	fmt.Fprintln(&sb, g.Transcript())
+	return os.WriteFile(file, []byte(sb.String()), 0644)
+}
+
+// loadGame reads a file written by saveGame, or any file with a move list.
+// Lines starting with '#' are headers, the players are taken from them if present.
+func loadGame(file string) (Setup, *Game, error) {
+	setup := Setup{computer: None, strength: defaultStrength}
+	f, err := os.Open(file)
+	if err != nil {
+		return setup, nil, err
+	}
+	defer f.Close()
+	var transcript strings.Builder
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "#") {
+			transcript.WriteString(line)
+			continue
+		}
+		header := strings.SplitN(strings.TrimSpace(line[1:]), ":", 2)
+		if len(header) != 2 {
+			continue
+		}
+		key, value := strings.TrimSpace(header[0]), strings.TrimSpace(header[1])
+		for _, p := range []Player{Black, White} {
+			if key != p.String() || !strings.HasPrefix(value, "Computer") {
+				continue
+			}
+			setup.computer = p
+			for i, s := range strengths {
+				if strings.Contains(value, "("+s.name+")") {
+					setup.strength = i
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return setup, nil, err
+	}
+	g, err := ParseTranscript(transcript.String())
+	if err != nil {
+		return setup, nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return setup, g, nil
+}
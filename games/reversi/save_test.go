@@ -0,0 +1,61 @@
+package reversi
+
+import (
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSaveRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		// play a whole game of random moves, passes included
+		g := NewGame()
+		for moves := g.Moves(); len(moves) > 0; moves = g.Moves() {
+			g.Play(moves[rng.Intn(len(moves))])
+		}
+		setup := Setup{computer: Player(1 + i%2), strength: i % len(strengths)}
+		file := filepath.Join(t.TempDir(), saveFile)
+		if err := saveGame(file, setup, g); err != nil {
+			t.Fatal(err)
+		}
+		loadedSetup, loaded, err := loadGame(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loadedSetup != setup {
+			t.Errorf("game %d: loaded setup %+v, want %+v", i, loadedSetup, setup)
+		}
+		if !reflect.DeepEqual(loaded, g) {
+			t.Errorf("game %d: loaded %s, want %s", i, loaded.Transcript(), g.Transcript())
+		}
+	}
+}
+
+func TestParseTranscriptErrors(t *testing.T) {
+	tests := []struct {
+		transcript string
+		err        string
+	}{
+		{"a1", "move 1: a1 is not legal for Black"},
+		{"f5f5", "move 2: f5 is not legal for White"},
+		{"f5 d6 d6", "move 3: d6 is not legal for Black"},
+		{"f5z9", "move 2: invalid cell \"z9\""},
+		{"f5d", "transcript has an odd number of characters"},
+	}
+	for _, tt := range tests {
+		_, err := ParseTranscript(tt.transcript)
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("ParseTranscript(%q) = %v, want %q", tt.transcript, err, tt.err)
+		}
+	}
+	// the moves are case insensitive and may be spaced out
+	g, err := ParseTranscript("F5 d6\nC3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Transcript() != "f5d6c3" {
+		t.Errorf("transcript is %s, want f5d6c3", g.Transcript())
+	}
+}
@@ -0,0 +1,209 @@
+package reversi
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"github.com/miluchen/games-in-go/games/search"
+	"golang.org/x/image/colornames"
+)
+
+const (
+	margin    = 30 // space around the board in pixels
+	hudHeight = 60 // space for the status on top of the board in pixels
+)
+
+type Scene struct {
+	setup    Setup
+	game     *Game
+	hints    bool     // whether legal moves are shown
+	thinking bool     // whether the AI is searching
+	aiMove   chan int // the move found by the AI
+	message  string   // pass notice or result of the last save
+}
+
+func newScene(setup Setup, game *Game) *Scene {
+	return &Scene{setup: setup, game: game, hints: true, aiMove: make(chan int, 1)}
+}
+
+func (s *Scene) update(win *pixelgl.Window) {
+	if win.JustPressed(pixelgl.KeyEscape) {
+		backToMenu()
+		return
+	}
+	if s.thinking {
+		select {
+		case move := <-s.aiMove:
+			s.play(move)
+			s.thinking = false
+		default:
+		}
+		s.draw(win)
+		return
+	}
+	switch {
+	case win.JustPressed(pixelgl.KeyH):
+		s.hints = !s.hints
+	case win.JustPressed(pixelgl.KeyU):
+		s.undo()
+	case win.JustPressed(pixelgl.KeyR):
+		startGame()
+		return
+	case win.JustPressed(pixelgl.KeyS):
+		s.save()
+	}
+	moves := s.game.Moves()
+	switch {
+	case len(moves) == 0:
+	case moves[0] == Pass:
+		// nothing to choose, pass right away
+		s.play(Pass)
+	case s.game.Turn() == s.setup.computer:
+		s.think()
+	case win.JustPressed(pixelgl.MouseButtonLeft):
+		if cell, ok := s.cellAt(win, win.MousePosition()); ok && s.game.Legal(cell) {
+			s.play(cell)
+		}
+	}
+	s.draw(win)
+}
+
+func (s *Scene) play(move int) {
+	if move == Pass {
+		s.message = fmt.Sprintf("%s has no legal move and passes", s.game.Turn())
+	} else {
+		s.message = ""
+	}
+	s.game.Play(move)
+}
+
+// think searches the AI move on a copy of the game, so the board can still be drawn meanwhile
+func (s *Scene) think() {
+	s.thinking = true
+	game, depth, result := s.game.Clone(), strengths[s.setup.strength].depth, s.aiMove
+	go func() {
+		move, _, _ := search.Best(game, depth)
+		result <- move
+	}()
+}
+
+// undo takes back moves until it's a human's turn with a move to make again
+func (s *Scene) undo() {
+	s.message = ""
+	for len(s.game.history) > 0 {
+		s.game.Undo()
+		if s.game.Turn() != s.setup.computer && !s.game.LastPassed() {
+			if ms := s.game.Moves(); len(ms) > 0 && ms[0] != Pass {
+				return
+			}
+		}
+	}
+}
+
+func (s *Scene) save() {
+	if err := saveGame(saveFile, s.setup, s.game); err != nil {
+		s.message = fmt.Sprintf("save failed: %v", err)
+		log.Printf("save game failed: %v\n", err)
+		return
+	}
+	s.message = fmt.Sprintf("saved to %s", saveFile)
+}
+
+// layout returns the size of a cell and the top left corner of the board
+func (s *Scene) layout(win *pixelgl.Window) (float64, pixel.Vec) {
+	unit := math.Floor(math.Min(win.Bounds().W()-2*margin, win.Bounds().H()-hudHeight-2*margin) / Size)
+	topLeft := pixel.V((win.Bounds().W()-unit*Size)/2, win.Bounds().H()-hudHeight-margin)
+	return unit, topLeft
+}
+
+// cellAt returns the cell under pos, row 1 is on top
+func (s *Scene) cellAt(win *pixelgl.Window, pos pixel.Vec) (int, bool) {
+	unit, topLeft := s.layout(win)
+	x := int(math.Floor((pos.X - topLeft.X) / unit))
+	y := int(math.Floor((topLeft.Y - pos.Y) / unit))
+	if x < 0 || x >= Size || y < 0 || y >= Size {
+		return 0, false
+	}
+	return index(x, y), true
+}
+
+func (s *Scene) status() string {
+	g := s.game
+	if g.Over() {
+		if g.Winner() == None {
+			return "Game over: draw!"
+		}
+		return fmt.Sprintf("Game over: %s wins!", g.Winner())
+	}
+	if s.thinking {
+		return fmt.Sprintf("%s (computer) is thinking...", g.Turn())
+	}
+	return fmt.Sprintf("%s to move", g.Turn())
+}
+
+func (s *Scene) draw(win *pixelgl.Window) {
+	win.Clear(colornames.Darkslategray)
+	unit, topLeft := s.layout(win)
+	center := func(cell int) pixel.Vec {
+		return topLeft.Add(pixel.V((float64(cell%Size)+0.5)*unit, -(float64(cell/Size)+0.5)*unit))
+	}
+	imd := imdraw.New(nil)
+	imd.Color = colornames.Darkgreen
+	imd.Push(topLeft, topLeft.Add(pixel.V(unit*Size, -unit*Size)))
+	imd.Rectangle(0)
+	imd.Color = colornames.Black
+	for i := 0; i <= Size; i++ {
+		imd.Push(topLeft.Add(pixel.V(float64(i)*unit, 0)), topLeft.Add(pixel.V(float64(i)*unit, -unit*Size)))
+		imd.Line(1)
+		imd.Push(topLeft.Add(pixel.V(0, -float64(i)*unit)), topLeft.Add(pixel.V(unit*Size, -float64(i)*unit)))
+		imd.Line(1)
+	}
+	for cell := 0; cell < Size*Size; cell++ {
+		switch s.game.At(cell%Size, cell/Size) {
+		case Black:
+			imd.Color = colornames.Black
+		case White:
+			imd.Color = colornames.White
+		default:
+			if s.hints && !s.thinking && s.game.Legal(cell) {
+				imd.Color = colornames.Lightgreen
+				imd.Push(center(cell))
+				imd.Circle(unit/8, 0)
+			}
+			continue
+		}
+		imd.Push(center(cell))
+		imd.Circle(unit*0.4, 0)
+	}
+	if cell, ok := s.game.LastMove(); ok {
+		imd.Color = colornames.Red
+		imd.Push(center(cell))
+		imd.Circle(unit/10, 0)
+	}
+	imd.Draw(win)
+
+	// draw column and row names
+	labels := text.New(pixel.ZV, atlas)
+	labels.Color = colornames.Lightgray
+	for i := 0; i < Size; i++ {
+		labels.Dot = topLeft.Add(pixel.V((float64(i)+0.5)*unit-3, 6))
+		fmt.Fprintf(labels, "%c", 'a'+i)
+		labels.Dot = topLeft.Add(pixel.V(-15, -(float64(i)+0.5)*unit-4))
+		fmt.Fprintf(labels, "%d", i+1)
+	}
+	labels.Draw(win, pixel.IM)
+
+	txt := text.New(pixel.ZV, atlas)
+	txt.Color = colornames.White
+	fmt.Fprintf(txt, "Black %d - %d White   %s\n", s.game.Count(Black), s.game.Count(White), s.status())
+	txt.Color = colornames.Yellow
+	fmt.Fprintln(txt, s.message)
+	txt.Color = colornames.Lightgray
+	fmt.Fprint(txt, "Click: move  H: hints  U: undo  S: save  R: restart  Esc: menu")
+	txt.Draw(win, pixel.IM.Moved(pixel.V(margin, win.Bounds().H()-txt.LineHeight-10)))
+}
@@ -0,0 +1,83 @@
+// Package search implements alpha-beta search for two-player zero-sum games with perfect information.
+// A game plugs in by implementing State.
+package search
+
+import "math"
+
+const (
+	// Win is the score of a won position, a game should evaluate a lost position as -Win.
+	// Wins found closer to the root score higher, so the shortest win is preferred.
+	Win = 1 << 20
+	// winThreshold separates win scores from heuristic scores
+	winThreshold = Win / 2
+	inf          = math.MaxInt32
+)
+
+// State is a game position that is searched by making and taking back moves in place
+type State interface {
+	// Moves returns the legal moves of the player to move, it's empty if the game is over.
+	// Searching the most promising moves first prunes more of the tree.
+	// A game with passes must return an explicit pass move.
+	Moves() []int
+	// Play makes move for the player to move
+	Play(move int)
+	// Undo takes back the last move
+	Undo()
+	// Evaluate scores the position from the view of the player to move,
+	// higher is better. Decided games score Win or -Win.
+	Evaluate() int
+}
+
+// Best returns the best move of the player to move found by searching depth moves ahead,
+// and its score. It returns ok false if there are no legal moves. The state is left unchanged.
+func Best(state State, depth int) (move int, score int, ok bool) {
+	moves := state.Moves()
+	if len(moves) == 0 {
+		return 0, 0, false
+	}
+	if depth < 1 {
+		depth = 1
+	}
+	alpha := -inf
+	for _, m := range moves {
+		state.Play(m)
+		s := -negamax(state, depth-1, 1, -inf, -alpha)
+		state.Undo()
+		if s > alpha || !ok {
+			move, alpha, ok = m, s, true
+		}
+	}
+	return move, alpha, ok
+}
+
+// negamax returns the score of state from the view of the player to move, ply moves below the root
+func negamax(state State, depth, ply int, alpha, beta int) int {
+	moves := state.Moves()
+	if depth == 0 || len(moves) == 0 {
+		// a win further down the tree is worth a bit less than a win closer to the root. It's counted
+		// from the root, so every score compared with alpha and beta is worth the same at any depth.
+		score := state.Evaluate()
+		if score > winThreshold {
+			score -= ply
+		} else if score < -winThreshold {
+			score += ply
+		}
+		return score
+	}
+	best := -inf
+	for _, m := range moves {
+		state.Play(m)
+		score := -negamax(state, depth-1, ply+1, -beta, -alpha)
+		state.Undo()
+		if score > best {
+			best = score
+		}
+		if best > alpha {
+			alpha = best
+		}
+		if alpha >= beta {
+			break
+		}
+	}
+	return best
+}
@@ -0,0 +1,114 @@
+package search
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// node is a position of a made up game, a leaf if it has no children
+type node struct {
+	children []*node
+	value    int // score from the view of the player to move
+}
+
+// tree plays the made up game, path goes from the root to the current position
+type tree struct {
+	path []*node
+}
+
+func (t *tree) Moves() []int {
+	var moves []int
+	for i := range t.path[len(t.path)-1].children {
+		moves = append(moves, i)
+	}
+	return moves
+}
+
+func (t *tree) Play(move int) {
+	t.path = append(t.path, t.path[len(t.path)-1].children[move])
+}
+
+func (t *tree) Undo() {
+	t.path = t.path[:len(t.path)-1]
+}
+
+func (t *tree) Evaluate() int {
+	return t.path[len(t.path)-1].value
+}
+
+// randomNode builds a tree of at most depth moves, with some games decided before the end
+func randomNode(rng *rand.Rand, depth int) *node {
+	n := &node{value: rng.Intn(21) - 10}
+	if depth == 0 || rng.Intn(4) == 0 {
+		switch rng.Intn(3) {
+		case 0:
+			n.value = Win
+		case 1:
+			n.value = -Win
+		}
+		return n
+	}
+	for i := 1 + rng.Intn(4); i > 0; i-- {
+		n.children = append(n.children, randomNode(rng, depth-1))
+	}
+	return n
+}
+
+// minimax scores state like negamax does, without pruning anything
+func minimax(state State, depth, ply int) int {
+	moves := state.Moves()
+	if depth == 0 || len(moves) == 0 {
+		score := state.Evaluate()
+		if score > winThreshold {
+			score -= ply
+		} else if score < -winThreshold {
+			score += ply
+		}
+		return score
+	}
+	best := -inf
+	for _, m := range moves {
+		state.Play(m)
+		if score := -minimax(state, depth-1, ply+1); score > best {
+			best = score
+		}
+		state.Undo()
+	}
+	return best
+}
+
+func TestBestMatchesMinimax(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 20000; i++ {
+		root := randomNode(rng, 6)
+		if len(root.children) == 0 {
+			continue
+		}
+		depth := 1 + rng.Intn(6)
+		state := &tree{path: []*node{root}}
+		move, score, ok := Best(state, depth)
+		if !ok {
+			t.Fatalf("tree %d: no move found", i)
+		}
+		if want := minimax(state, depth, 0); score != want {
+			t.Fatalf("tree %d, depth %d: scored %d, want %d", i, depth, score, want)
+		}
+		state.Play(move)
+		if got := -minimax(state, depth-1, 1); got != score {
+			t.Errorf("tree %d, depth %d: move %d scores %d, not the %d it was chosen for", i, depth, move, got, score)
+		}
+		if len(state.path) != 2 {
+			t.Fatalf("tree %d: the search left the state changed", i)
+		}
+	}
+}
+
+func TestBestPrefersTheShortestWin(t *testing.T) {
+	// the first move wins in three moves, the second one right away
+	slow := &node{children: []*node{{children: []*node{{value: -Win}}}}}
+	fast := &node{value: -Win}
+	state := &tree{path: []*node{{children: []*node{slow, fast}}}}
+	if move, score, _ := Best(state, 4); move != 1 || score != Win-1 {
+		t.Errorf("got move %d scoring %d, want move 1 scoring %d", move, score, Win-1)
+	}
+}
@@ -0,0 +1,73 @@
+// battle.go sets up battles, where the player competes with rival snakes steered by the computer
+
+package snake
+
+import (
+	"fmt"
+
+	"github.com/faiface/pixel"
+	"github.com/miluchen/games-in-go/games/snake/engine"
+)
+
+const maxRivals = 3 // rivals in a battle, with the player they take all the snake colors
+
+var rivalCount = 2  // number of rivals in the next battle
+var rivalChoice = 0 // 0 gives each rival another personality, otherwise they all play engine.Personalities[rivalChoice-1]
+
+var rivalsButton *RectButton      // battle menu button choosing the number of rivals
+var personalityButton *RectButton // battle menu button choosing the rivals' personality
+
+// battleRivals returns the personality of each rival of the next battle
+func battleRivals() []engine.Personality {
+	rivals := make([]engine.Personality, rivalCount)
+	for i := range rivals {
+		if rivalChoice == 0 {
+			rivals[i] = engine.Personalities[i%len(engine.Personalities)]
+		} else {
+			rivals[i] = engine.Personalities[rivalChoice-1]
+		}
+	}
+	return rivals
+}
+
+func createBattleMenu() *Menu {
+	menu := newMenu()
+	// add buttons for battle menu, the first two cycle through the choices
+	rect := pixel.Rect{Min: pixel.V(200, 310), Max: pixel.V(300, 340)}
+	rivalsButton = newRectButton(rect, rivalsLabel(), false, rivalsHandler)
+	menu.addButton(rivalsButton)
+	rect = pixel.Rect{Min: pixel.V(200, 270), Max: pixel.V(300, 300)}
+	personalityButton = newRectButton(rect, personalityLabel(), false, personalityHandler)
+	menu.addButton(personalityButton)
+	rect = pixel.Rect{Min: pixel.V(200, 230), Max: pixel.V(300, 260)}
+	menu.addButton(newRectButton(rect, startButtonName, false, startBattleHandler))
+	rect = pixel.Rect{Min: pixel.V(200, 190), Max: pixel.V(300, 220)}
+	menu.addButton(newRectButton(rect, backButtonName, false, backHandler))
+	return menu
+}
+
+func rivalsLabel() string {
+	return fmt.Sprintf("Rivals: %d", rivalCount)
+}
+
+func personalityLabel() string {
+	if rivalChoice == 0 {
+		return "AI: Mixed"
+	}
+	return fmt.Sprintf("AI: %v", engine.Personalities[rivalChoice-1])
+}
+
+func rivalsHandler() {
+	rivalCount = rivalCount%maxRivals + 1
+	rivalsButton.msg = rivalsLabel()
+}
+
+func personalityHandler() {
+	rivalChoice = (rivalChoice + 1) % (len(engine.Personalities) + 1)
+	personalityButton.msg = personalityLabel()
+}
+
+func startBattleHandler() {
+	mode = battleMode
+	startGame()
+}
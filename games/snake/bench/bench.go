@@ -0,0 +1,177 @@
+// Package bench plays seeded headless games with every snake strategy and compares the results.
+// The seeds are the same for every strategy, so runs can be compared across versions of the rules.
+package bench
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/miluchen/games-in-go/games/snake/engine"
+	"github.com/miluchen/games-in-go/games/snake/env"
+)
+
+// death causes in the order they are reported, starving is decided by the environment
+var causes = []string{engine.Wall.String(), engine.Self.String(), "starved"}
+
+// result of a single game
+type result struct {
+	score int
+	steps int
+	won   bool
+	cause string // empty if the snake won
+}
+
+// Report sums up the games played by one strategy
+type Report struct {
+	Strategy string
+	Games    int
+	Score    int            // total apples eaten
+	MaxScore int            // most apples eaten in one game
+	Steps    int            // total steps made
+	Wins     int            // games where the snake filled the board
+	Deaths   map[string]int // number of games ended by each cause
+}
+
+// AvgScore returns the average number of apples eaten per game
+func (r Report) AvgScore() float64 {
+	return float64(r.Score) / float64(r.Games)
+}
+
+// WinRate returns the share of games won
+func (r Report) WinRate() float64 {
+	return float64(r.Wins) / float64(r.Games)
+}
+
+// StepsPerApple returns how many steps an apple takes on average
+func (r Report) StepsPerApple() float64 {
+	if r.Score == 0 {
+		return 0
+	}
+	return float64(r.Steps) / float64(r.Score)
+}
+
+// Run plays games games with every strategy, game i uses seed+i. The reports are written as a table
+// to stdout, and as CSV to csvPath unless it's empty.
+func Run(games int, seed int64, csvPath string) error {
+	if games <= 0 {
+		return fmt.Errorf("number of games must be positive, got %d", games)
+	}
+	var reports []Report
+	for _, newStrategy := range engine.Strategies {
+		reports = append(reports, bench(newStrategy, games, seed))
+	}
+	writeTable(os.Stdout, reports)
+	if csvPath == "" {
+		return nil
+	}
+	f, err := os.Create(csvPath)
+	if err != nil {
+		return err
+	}
+	if err := writeCSV(f, reports); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// bench plays the games of one strategy, spread over one goroutine per CPU
+func bench(newStrategy func() engine.Strategy, games int, seed int64) Report {
+	results := make([]result, games)
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i] = play(newStrategy(), seed+int64(i))
+			}
+		}()
+	}
+	for i := 0; i < games; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	report := Report{Strategy: newStrategy().Name(), Games: games, Deaths: make(map[string]int)}
+	for _, r := range results {
+		report.Score += r.score
+		report.MaxScore = max(report.MaxScore, r.score)
+		report.Steps += r.steps
+		if r.won {
+			report.Wins++
+		} else {
+			report.Deaths[r.cause]++
+		}
+	}
+	return report
+}
+
+// play runs one game until the snake dies, starves or fills the board
+func play(strategy engine.Strategy, seed int64) result {
+	e := env.New(env.DefaultConfig)
+	e.Reset(seed)
+	steps := 0
+	for done := false; !done; steps++ {
+		_, _, done = e.Step(strategy.Next(e.Game()))
+	}
+	g := e.Game()
+	r := result{score: g.Score, steps: steps, won: g.Won}
+	switch {
+	case e.Starved():
+		r.cause = "starved"
+	case !g.Won:
+		r.cause = g.Cause.String()
+	}
+	return r
+}
+
+func writeTable(w io.Writer, reports []Report) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "strategy\tgames\tavg score\tmax score\twin rate\tsteps/apple\t")
+	for _, c := range causes {
+		fmt.Fprintf(tw, "%s\t", c)
+	}
+	fmt.Fprintln(tw)
+	for _, r := range reports {
+		fmt.Fprintf(tw, "%s\t%d\t%.1f\t%d\t%.1f%%\t%.1f\t", r.Strategy, r.Games, r.AvgScore(), r.MaxScore, 100*r.WinRate(), r.StepsPerApple())
+		for _, c := range causes {
+			fmt.Fprintf(tw, "%d\t", r.Deaths[c])
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+}
+
+func writeCSV(w io.Writer, reports []Report) error {
+	cw := csv.NewWriter(w)
+	header := []string{"strategy", "games", "avg_score", "max_score", "win_rate", "steps_per_apple"}
+	for _, c := range causes {
+		header = append(header, "deaths_"+c)
+	}
+	cw.Write(header)
+	for _, r := range reports {
+		record := []string{
+			r.Strategy,
+			strconv.Itoa(r.Games),
+			strconv.FormatFloat(r.AvgScore(), 'f', 2, 64),
+			strconv.Itoa(r.MaxScore),
+			strconv.FormatFloat(r.WinRate(), 'f', 4, 64),
+			strconv.FormatFloat(r.StepsPerApple(), 'f', 2, 64),
+		}
+		for _, c := range causes {
+			record = append(record, strconv.Itoa(r.Deaths[c]))
+		}
+		cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
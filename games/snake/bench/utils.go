@@ -0,0 +1,8 @@
+package bench
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
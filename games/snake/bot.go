@@ -0,0 +1,321 @@
+// bot.go lets an external program steer the snake, it talks JSON lines over the program's stdin/stdout
+
+package snake
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/miluchen/games-in-go/games/grid"
+	"github.com/miluchen/games-in-go/games/snake/engine"
+)
+
+const (
+	botTimeout = 200 * time.Millisecond // how long the bot may think about a move
+	botLogName = "./snake-bot.log"      // every message exchanged with the bot is logged here
+)
+
+// names of the directions in bot messages, y grows towards up
+var botDirections = map[grid.Direction]string{
+	grid.North:     "up",
+	grid.East:      "right",
+	grid.South:     "down",
+	grid.West:      "left",
+	grid.NorthEast: "up-right",
+	grid.SouthEast: "down-right",
+	grid.SouthWest: "down-left",
+	grid.NorthWest: "up-left",
+}
+
+// botState is sent to the bot before every step ("tick") and once when the game ends ("end")
+type botState struct {
+	Type      string      `json:"type"`
+	Tick      int         `json:"tick"`
+	Width     int         `json:"width"`
+	Height    int         `json:"height"`
+	Body      [][2]int    `json:"body"`   // head first
+	Apple     [2]int      `json:"apple"`  // the apple nearest to the head
+	Apples    [][2]int    `json:"apples"` // all the apples
+	Direction string      `json:"direction"`
+	Score     int         `json:"score"`
+	Alive     bool        `json:"alive"`
+	Won       bool        `json:"won"`
+	Wrap      bool        `json:"wrap"`    // whether the grid has no wall, the snake comes back on the opposite side
+	Walls     [][2]int    `json:"walls"`   // walls inside the grid
+	Portals   [][2][2]int `json:"portals"` // pairs of portals, the snake comes out past the other one
+	Hazards   [][2]int    `json:"hazards"` // where the hazards are now
+	Hex       bool        `json:"hex"`     // whether the cells are hexagons, with 6 directions
+}
+
+// botMove is what the bot answers to a tick, the tick is optional but lets late answers be told apart
+type botMove struct {
+	Tick      int    `json:"tick"`
+	Direction string `json:"direction"`
+}
+
+// botStrategy asks a bot subprocess where to go, a move that is late or illegal keeps the snake going straight
+type botStrategy struct {
+	command  string
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	lines    chan string // lines printed by the bot, closed when the bot exits
+	log      *log.Logger
+	logFile  *os.File
+	tick     int       // ticks sent in the current game
+	deadline time.Time // when the answer to the last tick is too late, zero if none is awaited
+}
+
+// newBotStrategy starts the bot, command is split on spaces into the program and its arguments
+func newBotStrategy(command string) (*botStrategy, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty bot command")
+	}
+	logFile, err := os.Create(botLogName)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		logFile.Close()
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		logFile.Close()
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		logFile.Close()
+		return nil, err
+	}
+	b := &botStrategy{
+		command: command,
+		cmd:     cmd,
+		stdin:   stdin,
+		lines:   make(chan string),
+		log:     log.New(logFile, "", log.Ltime|log.Lmicroseconds),
+		logFile: logFile,
+	}
+	b.log.Printf("started %q", command)
+	// read in the background, so the game can go on drawing while the bot thinks
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			b.lines <- scanner.Text()
+		}
+		close(b.lines)
+	}()
+	return b, nil
+}
+
+func (b *botStrategy) Name() string {
+	return "bot " + b.command
+}
+
+// Next waits for the bot's move, poll asks for it without waiting
+func (b *botStrategy) Next(g *engine.Game) grid.Direction {
+	dir, answered := b.poll(g)
+	for !answered {
+		select {
+		case line, ok := <-b.lines:
+			dir, answered = b.read(line, ok, g)
+		case <-time.After(time.Until(b.deadline)):
+			dir, answered = b.timeout(g), true
+		}
+	}
+	return dir
+}
+
+// poll asks the bot for its move on the first call and reports whether the answer came on the
+// next ones, it never waits. It's called on every frame until the bot answers or times out, so
+// a slow bot doesn't stop the game from being drawn.
+func (b *botStrategy) poll(g *engine.Game) (grid.Direction, bool) {
+	if b.lines == nil {
+		// the bot has exited
+		return g.Dir, true
+	}
+	if b.deadline.IsZero() {
+		b.tick++
+		// answers arriving after their timeout are dropped, so they aren't taken for this tick
+		b.drain()
+		if !b.send(b.state("tick", g)) {
+			return g.Dir, true
+		}
+		b.deadline = time.Now().Add(botTimeout)
+	}
+	for {
+		select {
+		case line, ok := <-b.lines:
+			if dir, answered := b.read(line, ok, g); answered {
+				return dir, true
+			}
+		default:
+			if time.Now().After(b.deadline) {
+				return b.timeout(g), true
+			}
+			return g.Dir, false
+		}
+	}
+}
+
+// read handles a line printed by the bot while a tick is awaited, ok is false once the bot has
+// exited. It reports whether the line answers the tick.
+func (b *botStrategy) read(line string, ok bool, g *engine.Game) (grid.Direction, bool) {
+	if !ok {
+		b.log.Printf("! bot has exited, going straight")
+		b.lines = nil
+		b.deadline = time.Time{}
+		return g.Dir, true
+	}
+	var move botMove
+	if err := json.Unmarshal([]byte(line), &move); err != nil {
+		b.log.Printf("< %s", line)
+		b.log.Printf("! illegal move: %v, going straight", err)
+		b.deadline = time.Time{}
+		return g.Dir, true
+	}
+	if move.Tick != 0 && move.Tick != b.tick {
+		b.log.Printf("< %s (late, dropped)", line)
+		return g.Dir, false
+	}
+	b.log.Printf("< %s", line)
+	b.deadline = time.Time{}
+	return b.direction(move, g), true
+}
+
+// timeout gives up on the answer to the tick
+func (b *botStrategy) timeout(g *engine.Game) grid.Direction {
+	b.log.Printf("! tick %d timed out after %v, going straight", b.tick, botTimeout)
+	b.deadline = time.Time{}
+	return g.Dir
+}
+
+// gameOver tells the bot how the game ended, the next game starts from tick 1 again
+func (b *botStrategy) gameOver(g *engine.Game) {
+	b.send(b.state("end", g))
+	b.tick = 0
+	b.deadline = time.Time{}
+}
+
+// close stops the bot, closing its stdin gives it a chance to exit by itself
+func (b *botStrategy) close() {
+	b.stdin.Close()
+	done := make(chan struct{})
+	go func() {
+		b.cmd.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		b.cmd.Process.Kill()
+		<-done
+	}
+	b.log.Printf("stopped")
+	b.logFile.Close()
+}
+
+// state describes the game to the bot
+func (b *botStrategy) state(kind string, g *engine.Game) botState {
+	// the head is the last cell of the body, bots get it first
+	body := make([][2]int, len(g.Body))
+	for i, p := range g.Body {
+		body[len(body)-1-i] = [2]int{p.X, p.Y}
+	}
+	apple := g.NearestApple(g.Head())
+	apples := [][2]int{}
+	for _, p := range g.Apples {
+		apples = append(apples, [2]int{p.X, p.Y})
+	}
+	walls := [][2]int{}
+	for _, p := range g.Walls {
+		walls = append(walls, [2]int{p.X, p.Y})
+	}
+	portals := [][2][2]int{}
+	for _, pair := range g.Portals {
+		portals = append(portals, [2][2]int{{pair[0].X, pair[0].Y}, {pair[1].X, pair[1].Y}})
+	}
+	hazards := [][2]int{}
+	for _, h := range g.Hazards {
+		hazards = append(hazards, [2]int{h.At.X, h.At.Y})
+	}
+	return botState{
+		Type:      kind,
+		Tick:      b.tick,
+		Width:     g.Width,
+		Height:    g.Height,
+		Body:      body,
+		Apple:     [2]int{apple.X, apple.Y},
+		Apples:    apples,
+		Direction: botDirections[g.Dir],
+		Score:     g.Score,
+		Alive:     g.Alive,
+		Won:       g.Won,
+		Wrap:      g.Wrap,
+		Walls:     walls,
+		Portals:   portals,
+		Hazards:   hazards,
+		Hex:       g.Topology == grid.Hex,
+	}
+}
+
+// send writes one message to the bot, it returns false if the bot can't be reached
+func (b *botStrategy) send(state botState) bool {
+	if b.lines == nil {
+		return false
+	}
+	msg, err := json.Marshal(state)
+	if err != nil {
+		log.Printf("encode bot message failed: %v\n", err)
+		return false
+	}
+	b.log.Printf("> %s", msg)
+	if _, err := fmt.Fprintf(b.stdin, "%s\n", msg); err != nil {
+		b.log.Printf("! write failed: %v", err)
+		return false
+	}
+	return true
+}
+
+// direction checks the bot's move, anything but a legal direction keeps the snake going straight
+func (b *botStrategy) direction(move botMove, g *engine.Game) grid.Direction {
+	dir := g.Dir
+	for _, d := range g.Directions() {
+		if botDirections[d] != move.Direction {
+			continue
+		}
+		if d == dir.Opposite() {
+			b.log.Printf("! illegal move: %q reverses the snake, going straight", move.Direction)
+			return dir
+		}
+		return d
+	}
+	b.log.Printf("! illegal move: unknown direction %q, going straight", move.Direction)
+	return dir
+}
+
+// drain drops the lines the bot printed too late
+func (b *botStrategy) drain() {
+	for b.lines != nil {
+		select {
+		case line, ok := <-b.lines:
+			if !ok {
+				b.lines = nil
+				return
+			}
+			b.log.Printf("< %s (late, dropped)", line)
+		default:
+			return
+		}
+	}
+}
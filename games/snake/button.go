@@ -2,6 +2,7 @@ package snake
 
 import (
 	"fmt"
+	"log"
 	"strings"
 
 	"github.com/faiface/pixel"
@@ -73,7 +74,17 @@ func (b *RectButton) handle() {
 /* ================ button names ================ */
 const (
 	newGameButtonName     = "New Game"
+	classicButtonName     = "Classic"
+	endlessButtonName     = "Endless"
+	timeAttackButtonName  = "Time Attack"
+	survivalButtonName    = "Survival"
 	leaderBoardButtonName = "Leaderboard"
+	demoButtonName        = "AI Demo"
+	practiceButtonName    = "Practice"
+	versusButtonName      = "2 Players"
+	battleButtonName      = "Battle"
+	dailyButtonName       = "Daily Challenge"
+	startButtonName       = "Start"
 	optionsButtonName     = "Options"
 	exitButtonName        = "Exit"
 	resumeButtonName      = "Resume"
@@ -89,13 +100,102 @@ const (
 
 /* ================ callbacks for buttons ================ */
 func newGameHandler() {
+	menuStack = append(menuStack, newGameMenu)
+}
+
+func classicHandler() {
+	mode = classicMode
+	startGame()
+}
+
+func endlessHandler() {
+	mode = endlessMode
+	startGame()
+}
+
+func timeAttackHandler() {
+	menuStack = append(menuStack, timeAttackMenu)
+}
+
+func survivalHandler() {
+	mode = survivalMode
 	startGame()
 }
 
+func practiceHandler() {
+	mode = practiceMode
+	startGame()
+}
+
+func versusHandler() {
+	mode = versusMode
+	startGame()
+}
+
+func battleHandler() {
+	menuStack = append(menuStack, battleMenu)
+}
+
+func demoHandler() {
+	startDemo()
+}
+
+// rankingHandler shows the next leaderboard category
+func rankingHandler() {
+	rankingIndex = (rankingIndex + 1) % len(rankings)
+	rankingButton.msg = rankings[rankingIndex].title
+	leaderboardMenu.stale = true
+}
+
 func leaderboardHandler() {
+	leaderboardMenu.stale = true
 	menuStack = append(menuStack, leaderboardMenu)
 }
 
+// wallsHandler switches the wall of the next games on or off
+func wallsHandler() {
+	wrap = !wrap
+	wallsButton.msg = wallsLabel()
+}
+
+func wallsLabel() string {
+	if wrap {
+		return "Walls: Off"
+	}
+	return "Walls: On"
+}
+
+// appleCounts are the numbers of apples the options menu cycles through
+var appleCounts = []int{1, 2, 3, 5}
+
+// applesHandler sets the number of apples of the next games to the next choice
+func applesHandler() {
+	for i, count := range appleCounts {
+		if count == appleCount {
+			appleCount = appleCounts[(i+1)%len(appleCounts)]
+			break
+		}
+	}
+	applesButton.msg = applesLabel()
+}
+
+func applesLabel() string {
+	return fmt.Sprintf("Apples: %d", appleCount)
+}
+
+// gridHandler switches the cells of the next games between squares and hexagons
+func gridHandler() {
+	hexGrid = !hexGrid
+	gridButton.msg = gridLabel()
+}
+
+func gridLabel() string {
+	if hexGrid {
+		return "Grid: Hex"
+	}
+	return "Grid: Square"
+}
+
 func optionsHandler() {
 	menuStack = append(menuStack, optionsMenu)
 }
@@ -147,7 +247,12 @@ func confirmHandler() {
 	// write data into database
 	name := strings.TrimSpace(inputNameMenu.inputBoxes[0].input)
 	if len(name) > 0 {
-		db.Insert(name)
+		snakeGame := currentScene.snakeGame
+		if board, ok := snakeGame.board(); ok {
+			if err := db.Insert(name, board, snakeGame.Score); err != nil {
+				log.Printf("insert score failed: %v\n", err)
+			}
+		}
 	}
 	// reset input box
 	inputNameMenu.inputBoxes[0].reset()
@@ -0,0 +1,149 @@
+// daily.go sets up the daily challenge: one game a day on maze levels and apples that come from the
+// local date, so everyone playing on the same day plays the same game
+
+package snake
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"github.com/miluchen/games-in-go/games/snake/db"
+	"golang.org/x/image/colornames"
+	"golang.org/x/image/font/basicfont"
+)
+
+var dailyDay time.Time // date of the daily challenge started last
+
+var dailyMenu *Menu
+var dailyOverMenu *Menu
+var dailyStartButton *RectButton // daily menu button starting today's game, disabled once it's played
+
+// dailySeed returns the seed of the daily challenge of day, e.g. 20261019. It only depends on the
+// date, so the levels and the apples are the same for everyone.
+func dailySeed(day time.Time) int64 {
+	return int64(day.Year()*10000 + int(day.Month())*100 + day.Day())
+}
+
+func createDailyMenu() *Menu {
+	menu := newMenu()
+	menu.generate = generateDaily
+	// add buttons for daily menu
+	rect := pixel.Rect{Min: pixel.V(200, 150), Max: pixel.V(300, 180)}
+	dailyStartButton = newRectButton(rect, startButtonName, false, startDailyHandler)
+	menu.addButton(dailyStartButton)
+	rect = pixel.Rect{Min: pixel.V(200, 110), Max: pixel.V(300, 140)}
+	menu.addButton(newRectButton(rect, backButtonName, false, backHandler))
+	return menu
+}
+
+// generateDaily shows today's challenge: whether it's played yet, the streak and the day's leaderboard
+func generateDaily(win *pixelgl.Window, menu *Menu) {
+	day := time.Now()
+	atlas := text.NewAtlas(basicfont.Face7x13, text.ASCII)
+	txt := text.New(pixel.V(100, 700), atlas)
+	txt.Color = colornames.Green
+	fmt.Fprintf(txt, "Daily Challenge - %s\n", day.Format("Jan 2, 2006"))
+	matrix := pixel.IM.Moved(win.Bounds().Center().Sub(txt.Bounds().Center()).Add(pixel.V(0, win.Bounds().H()/2-txt.Bounds().H()/2)))
+
+	menu.texts = nil
+	menu.textMatrices = nil
+	menu.addText(txt, matrix)
+
+	score, played, err := db.DailyScore(day)
+	if err != nil {
+		txt.Color = colornames.Red
+		fmt.Fprintf(txt, "err: %s\n", err.Error())
+		dailyStartButton.disabled = true
+		return
+	}
+	dailyStartButton.disabled = played
+	streak, err := db.Streak(day)
+	if err != nil {
+		log.Printf("read streak failed: %v\n", err)
+	}
+	txt.Color = colornames.Greenyellow
+	if played {
+		fmt.Fprintf(txt, "Played: %d, back tomorrow\n", score)
+	} else {
+		fmt.Fprintln(txt, "One attempt, walls on, 1 apple")
+	}
+	fmt.Fprintf(txt, "Streak: %s\n", streakLabel(streak))
+	entries, err := db.Read(db.DailyMode(day))
+	if err != nil {
+		txt.Color = colornames.Red
+		fmt.Fprintf(txt, "err: %s\n", err.Error())
+		return
+	}
+	for i, entry := range entries {
+		fmt.Fprintf(txt, "%d\t%-16s%d\n", i+1, entry.Name, entry.Score)
+	}
+}
+
+func streakLabel(streak int) string {
+	if streak == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", streak)
+}
+
+func dailyHandler() {
+	dailyMenu.stale = true
+	menuStack = append(menuStack, dailyMenu)
+}
+
+// startDailyHandler starts today's challenge, the attempt counts from now on even if the game is left
+func startDailyHandler() {
+	day := time.Now()
+	started, err := db.StartDaily(day)
+	if err != nil {
+		log.Printf("start daily challenge failed: %v\n", err)
+		return
+	}
+	if !started {
+		// it was played already, the menu tells so
+		dailyMenu.stale = true
+		return
+	}
+	dailyDay = day
+	mode = dailyMode
+	startGame()
+}
+
+func createDailyOverMenu() *Menu {
+	menu := newMenu()
+	// the result text is set when a daily challenge ends, there is no playing again
+	rect := pixel.Rect{Min: pixel.V(200, 230), Max: pixel.V(300, 260)}
+	menu.addButton(newRectButton(rect, leaderBoardButtonName, false, dailyHandler))
+	rect = pixel.Rect{Min: pixel.V(200, 190), Max: pixel.V(300, 220)}
+	menu.addButton(newRectButton(rect, mainMenuButtonName, false, mainMenuHandler))
+	return menu
+}
+
+// showDailyResult records the score of the daily challenge and shows how it went
+func showDailyResult(win *pixelgl.Window, snakeGame *SnakeGame) {
+	if err := db.FinishDaily(snakeGame.day, snakeGame.Score); err != nil {
+		log.Printf("record daily challenge failed: %v\n", err)
+	}
+	streak, err := db.Streak(snakeGame.day)
+	if err != nil {
+		log.Printf("read streak failed: %v\n", err)
+	}
+	atlas := text.NewAtlas(basicfont.Face7x13, text.ASCII)
+	txt := text.New(pixel.V(100, 700), atlas)
+	txt.Color = colornames.Red
+	fmt.Fprintf(txt, "Daily Challenge - %s\n", snakeGame.day.Format("Jan 2, 2006"))
+	fmt.Fprintf(txt, "Score: %d\n", snakeGame.Score)
+	fmt.Fprintf(txt, "Apples: %d\n", snakeGame.Eaten)
+	fmt.Fprintf(txt, "Level: %d\n", snakeGame.level)
+	fmt.Fprintf(txt, "Streak: %s\n", streakLabel(streak))
+	matrix := pixel.IM.Moved(win.Bounds().Center().Sub(txt.Bounds().Center()).Add(pixel.V(0, win.Bounds().H()/2-txt.Bounds().H()/2)))
+
+	dailyOverMenu.texts = nil
+	dailyOverMenu.textMatrices = nil
+	dailyOverMenu.addText(txt, matrix)
+	menuStack = append(menuStack, dailyOverMenu)
+}
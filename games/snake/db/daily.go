@@ -0,0 +1,90 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+)
+
+// dayFormat is how a day is written in the daily table and the daily leaderboard modes
+const dayFormat = "2006-01-02"
+
+// DailyMode returns the leaderboard mode of the daily challenge of day, each day has its own
+func DailyMode(day time.Time) string {
+	return "daily-" + day.Format(dayFormat)
+}
+
+// StartDaily records the attempt at the daily challenge of day, it returns false if it was
+// already made. The attempt counts from then on, even if the game isn't finished.
+func StartDaily(day time.Time) (bool, error) {
+	if gameDB == nil {
+		return false, errNotOpen
+	}
+	result, err := gameDB.Exec("insert or ignore into daily(day) values(?)", day.Format(dayFormat))
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// FinishDaily records the score of the daily challenge of day
+func FinishDaily(day time.Time, score int) error {
+	if gameDB == nil {
+		return errNotOpen
+	}
+	_, err := gameDB.Exec("update daily set score = ? where day = ?", score, day.Format(dayFormat))
+	return err
+}
+
+// DailyScore returns the score of the daily challenge of day, and whether it was played
+func DailyScore(day time.Time) (int, bool, error) {
+	if gameDB == nil {
+		return 0, false, errNotOpen
+	}
+	var score int
+	err := gameDB.QueryRow("select score from daily where day = ?", day.Format(dayFormat)).Scan(&score)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return score, true, nil
+}
+
+// Streak returns the number of days in a row the daily challenge was played up to day. A streak
+// isn't broken yet while the challenge of day is still to be played, it then ends the day before.
+func Streak(day time.Time) (int, error) {
+	if gameDB == nil {
+		return 0, errNotOpen
+	}
+	rows, err := gameDB.Query("select day from daily order by day desc")
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	played := make(map[string]bool)
+	for rows.Next() {
+		var d string
+		if err = rows.Scan(&d); err != nil {
+			return 0, err
+		}
+		played[d] = true
+	}
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+	if !played[day.Format(dayFormat)] {
+		day = day.AddDate(0, 0, -1)
+	}
+	streak := 0
+	for played[day.Format(dayFormat)] {
+		streak++
+		day = day.AddDate(0, 0, -1)
+	}
+	return streak, nil
+}
@@ -1,60 +1,334 @@
 package db
 
 import (
+	"bytes"
+	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/miluchen/games-in-go/games/leaderboard"
 )
 
-const dbName = "./.game.db"
+const (
+	dbName    = "./.game.db"
+	game      = "snake"          // game the scores are submitted for
+	top       = 10               // scores shown on the leaderboard
+	timeout   = 2 * time.Second  // how long to wait for the leaderboard server
+	syncEvery = 30 * time.Second // how often queued scores are retried
+	refresh   = 5 * time.Second  // how long the scores read from the leaderboard server are shown before being read again
+)
 
 var gameDB *sql.DB
 
+// errNotOpen is returned when the db couldn't be opened, the game goes on without records then
+var errNotOpen = errors.New("db is not open")
+
+var server string // url of the leaderboard server, empty to only keep scores locally
+var client = &http.Client{Timeout: timeout}
+var wake chan struct{} // asks the sync loop to submit the queued scores now
+var stop chan struct{} // stops the sync loop
+var stopped sync.WaitGroup
+
+// the best scores read from the leaderboard server, they are read in the background so the game
+// never waits for the server
+var remoteLock sync.Mutex                    // guards the variables below
+var remoteEntries = make(map[string][]Entry) // best scores the server sent last, by mode
+var fetchedAt = make(map[string]time.Time)   // when the scores of a mode were last read, or failed to be
+var fetching = make(map[string]bool)         // modes being read
+var updates int                              // number of reads done, see Updates
+
+// Entry is a score on the leaderboard
+type Entry struct {
+	Name  string
+	Score int
+}
+
 func Open() error {
 	db, err := sql.Open("sqlite3", dbName)
 	if err != nil {
 		return err
 	}
-	// create table
-	sqlStmt := "create table if not exists snake (id integer not null primary key, name text);"
+	// create tables, pending holds the scores the leaderboard server hasn't got yet and daily the
+	// daily challenges played here
+	sqlStmt := `create table if not exists snake (id integer not null primary key, name text);
+	create table if not exists pending (
+		id text not null primary key,
+		mode text not null,
+		name text not null,
+		score integer not null,
+		time timestamp not null
+	);
+	create table if not exists daily (
+		day text not null primary key,
+		score integer not null default 0
+	);`
 	_, err = db.Exec(sqlStmt)
 	if err != nil {
 		return err
 	}
+	// scores and modes came after the first databases were made
+	if err = addColumn(db, "score", "integer not null default 0"); err != nil {
+		return err
+	}
+	if err = addColumn(db, "mode", "text not null default 'classic'"); err != nil {
+		return err
+	}
 	gameDB = db
 	return nil
 }
 
-func Insert(name string) error {
-	stmt := "insert into snake(name) values(?)"
-	_, err := gameDB.Exec(stmt, name)
+// addColumn adds a column to the snake table if it doesn't have it yet
+func addColumn(db *sql.DB, name, definition string) error {
+	rows, err := db.Query("select name from pragma_table_info('snake')")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var column string
+		if err = rows.Scan(&column); err != nil {
+			return err
+		}
+		if column == name {
+			return nil
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	_, err = db.Exec(fmt.Sprintf("alter table snake add column %s %s", name, definition))
 	return err
 }
 
-func Read() ([]string, error) {
-	rows, err := gameDB.Query("select name from snake")
+// SetServer shares the scores on the leaderboard server at url, e.g. http://localhost:8080. Scores
+// are still stored locally, the ones the server can't be reached for are queued and submitted once
+// it's back.
+func SetServer(url string) {
+	server = url
+	wake = make(chan struct{}, 1)
+	stop = make(chan struct{})
+	stopped.Add(1)
+	go syncLoop()
+	Sync()
+}
+
+// Insert stores a score, and submits it to the leaderboard server if there is one
+func Insert(name, mode string, score int) error {
+	_, err := gameDB.Exec("insert into snake(name, mode, score) values(?, ?, ?)", name, mode, score)
+	if err != nil || server == "" {
+		return err
+	}
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+	stmt := "insert into pending(id, mode, name, score, time) values(?, ?, ?, ?, ?)"
+	if _, err = gameDB.Exec(stmt, id, mode, name, score, time.Now().UTC()); err != nil {
+		return err
+	}
+	Sync()
+	return nil
+}
+
+// Read returns the best scores of a mode, from the leaderboard server when it could be reached and
+// from the local database otherwise. It doesn't wait for the server: the scores the server sent
+// last are returned, and read again in the background once they are older than refresh.
+func Read(mode string) ([]Entry, error) {
+	if server != "" {
+		remoteLock.Lock()
+		entries, ok := remoteEntries[mode]
+		if !fetching[mode] && time.Since(fetchedAt[mode]) > refresh {
+			fetching[mode] = true
+			go fetch(mode)
+		}
+		remoteLock.Unlock()
+		if ok {
+			return entries, nil
+		}
+	}
+	rows, err := gameDB.Query("select name, score from snake where mode = ? order by score desc, id limit ?", mode, top)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var names []string
+	var entries []Entry
 	for rows.Next() {
-		var name string
-		err = rows.Scan(&name)
+		var entry Entry
+		err = rows.Scan(&entry.Name, &entry.Score)
 		if err != nil {
 			return nil, err
 		}
-		names = append(names, name)
+		entries = append(entries, entry)
 	}
 	err = rows.Err()
 	if err != nil {
 		return nil, err
 	}
-	return names, nil
+	return entries, nil
+}
+
+// fetch reads the best scores of a mode from the leaderboard server, a server that can't be reached
+// leaves the local scores shown
+func fetch(mode string) {
+	entries, err := readRemote(mode)
+	remoteLock.Lock()
+	defer remoteLock.Unlock()
+	if err != nil {
+		log.Printf("read leaderboard failed, showing local scores: %v\n", err)
+		delete(remoteEntries, mode)
+	} else {
+		remoteEntries[mode] = entries
+	}
+	delete(fetching, mode)
+	fetchedAt[mode] = time.Now()
+	updates++
+}
+
+// expire has the scores of mode read again from the leaderboard server, e.g. after a new score
+// reached it
+func expire(mode string) {
+	remoteLock.Lock()
+	defer remoteLock.Unlock()
+	delete(fetchedAt, mode)
+	updates++
+}
+
+// Updates returns the number of times scores were read from the leaderboard server in the
+// background, a leaderboard shown is read again when it changes
+func Updates() int {
+	remoteLock.Lock()
+	defer remoteLock.Unlock()
+	return updates
+}
+
+func readRemote(mode string) ([]Entry, error) {
+	query := url.Values{"game": {game}, "mode": {mode}, "n": {fmt.Sprint(top)}}
+	resp, err := client.Get(server + "/scores?" + query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("leaderboard server answered %s", resp.Status)
+	}
+	var scores []leaderboard.Score
+	if err = json.NewDecoder(resp.Body).Decode(&scores); err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, len(scores))
+	for i, score := range scores {
+		entries[i] = Entry{Name: score.Name, Score: score.Score}
+	}
+	return entries, nil
+}
+
+// Sync asks for the queued scores to be submitted to the leaderboard server, without waiting
+func Sync() {
+	if server == "" {
+		return
+	}
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// syncLoop submits the queued scores when woken up, and retries them every syncEvery
+func syncLoop() {
+	defer stopped.Done()
+	ticker := time.NewTicker(syncEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-wake:
+		case <-ticker.C:
+		}
+		if err := submitPending(); err != nil {
+			log.Printf("submit scores failed, retrying later: %v\n", err)
+		}
+	}
+}
+
+// submitPending submits the queued scores in the order they were made, it stops at the first one
+// the server can't take so the rest wait for the next try
+func submitPending() error {
+	rows, err := gameDB.Query("select id, mode, name, score, time from pending order by time")
+	if err != nil {
+		return err
+	}
+	var scores []leaderboard.Score
+	for rows.Next() {
+		score := leaderboard.Score{Game: game}
+		if err = rows.Scan(&score.ID, &score.Mode, &score.Name, &score.Score, &score.Time); err != nil {
+			rows.Close()
+			return err
+		}
+		scores = append(scores, score)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	for _, score := range scores {
+		if err = submit(score); err != nil {
+			return err
+		}
+		if _, err = gameDB.Exec("delete from pending where id = ?", score.ID); err != nil {
+			return err
+		}
+		expire(score.Mode)
+	}
+	return nil
+}
+
+func submit(score leaderboard.Score) error {
+	body, err := json.Marshal(score)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Post(server+"/scores", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode/100 == 2:
+		return nil
+	case resp.StatusCode/100 == 4:
+		// the server will never take it, retrying would block the queue
+		log.Printf("leaderboard server refused score %s: %s\n", score.ID, resp.Status)
+		return nil
+	}
+	return fmt.Errorf("leaderboard server answered %s", resp.Status)
+}
+
+// newID returns a random id, the server stores a score submitted twice with the same id once
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
 }
 
 func Close() error {
+	if stop != nil {
+		close(stop)
+		stopped.Wait()
+		stop = nil
+	}
 	if gameDB != nil {
 		return gameDB.Close()
 	}
@@ -0,0 +1,176 @@
+// autopilot.go contains strategies that steer the snake instead of a player
+
+package engine
+
+import "github.com/miluchen/games-in-go/games/grid"
+
+// Strategy decides where the snake goes, it's asked once per step
+type Strategy interface {
+	Name() string
+	// Next returns the direction the snake should move in on its next step
+	Next(g *Game) grid.Direction
+}
+
+// Strategies creates the built-in strategies, the demo cycles through them in this order
+var Strategies = []func() Strategy{newGreedyStrategy, newHamiltonStrategy}
+
+/* ========== greedy strategy ========== */
+
+// greedyStrategy goes to the nearest apple along the shortest path, as long as the snake can still reach
+// its tail afterwards. Otherwise it follows its tail until a safe path opens up.
+type greedyStrategy struct{}
+
+func newGreedyStrategy() Strategy {
+	return greedyStrategy{}
+}
+
+func (greedyStrategy) Name() string {
+	return "Greedy A*"
+}
+
+func (greedyStrategy) Next(g *Game) grid.Direction {
+	head := g.Head()
+	if path := g.safePath(g.Body, g.NearestApple(head)); path != nil {
+		return g.directionTo(head, path[0])
+	}
+	return stall(g, g.Snake, nil)
+}
+
+// stall returns a move that keeps the snake alive without going for the apple: the move after which
+// the tail is reachable along the longest way, which stretches the snake out so a safe path to the
+// apple opens up, or the move with the most room if the tail can't be reached anymore.
+// s is the snake to move, others are the bodies of the snakes in its way.
+func stall(g *Game, s *Snake, others [][]grid.Point) grid.Direction {
+	head := s.Head()
+	b := g.newBoard(s.Body, others...)
+	best, bestScore := s.Dir, -1
+	for _, dir := range g.Directions() {
+		n := g.Neighbor(head, dir)
+		if dir == s.Dir.Opposite() || !b.enterable(n, 1) {
+			continue
+		}
+		moved := follow(s.Body, []grid.Point{n}, g.edible(n))
+		score, steps := g.newStillBoard(moved, others...).reachable(n, moved[0])
+		if steps != -1 {
+			// any move that keeps the tail in reach beats any move that doesn't
+			score = g.Width*g.Height + steps
+		}
+		if score > bestScore {
+			best, bestScore = dir, score
+		}
+	}
+	return best
+}
+
+/* ========== hamiltonian cycle strategy ========== */
+
+// hamiltonStrategy follows a cycle through every cell of the board, either way round. Once the body
+// lies along the cycle behind the head, the cells ahead up to the tail are free, so following the
+// cycle never runs into the body and fills the board for sure. Such a cycle only exists when width
+// or height is even, on a board where both are odd the greedy strategy plays instead. So it does on
+// boards with walls inside or portals, which have no such cycle in general, against hazards and on
+// hex cells.
+type hamiltonStrategy struct {
+	width  int // size of the board the cycle is built for
+	height int
+	cycle  []grid.Point // cells in the order they are visited
+	order  map[grid.Point]int
+	way    int // 1 to go along the cycle, -1 to go round it backwards
+}
+
+func newHamiltonStrategy() Strategy {
+	return &hamiltonStrategy{way: 1}
+}
+
+// fit builds the cycle again if the board's size changed
+func (h *hamiltonStrategy) fit(width, height int) {
+	if h.width == width && h.height == height {
+		return
+	}
+	*h = hamiltonStrategy{width: width, height: height, order: make(map[grid.Point]int), way: 1}
+	h.build(width, height)
+	for i, p := range h.cycle {
+		h.order[p] = i
+	}
+}
+
+func (h *hamiltonStrategy) Name() string {
+	return "Hamiltonian cycle"
+}
+
+// build constructs the cycle for a width x height board, one of them even: rows zigzag over every
+// column but the first one, which leads back to the start. This needs an even number of rows, so
+// an odd height is transposed.
+func (h *hamiltonStrategy) build(width, height int) {
+	if width < 2 || height < 2 {
+		return
+	}
+	if height%2 == 1 {
+		h.build(height, width)
+		for i, p := range h.cycle {
+			h.cycle[i] = grid.Point{X: p.Y, Y: p.X}
+		}
+		return
+	}
+	for y := 0; y < height; y++ {
+		for i := 1; i < width; i++ {
+			x := i
+			if y%2 == 1 {
+				x = width - i
+			}
+			h.cycle = append(h.cycle, grid.Point{X: x, Y: y})
+		}
+	}
+	for y := height - 1; y >= 0; y-- {
+		h.cycle = append(h.cycle, grid.Point{X: 0, Y: y})
+	}
+}
+
+// next returns the index in the cycle of the cell after cycle[i]
+func (h *hamiltonStrategy) next(i int) int {
+	return (i + h.way + len(h.cycle)) % len(h.cycle)
+}
+
+// aligned tells whether the body lies along the cycle, each cell right behind the next one
+func (h *hamiltonStrategy) aligned(body []grid.Point, way int) bool {
+	for i := 1; i < len(body); i++ {
+		prev, ok := h.order[body[i-1]]
+		cur, ok2 := h.order[body[i]]
+		if !ok || !ok2 || (prev+way+len(h.cycle))%len(h.cycle) != cur {
+			return false
+		}
+	}
+	return true
+}
+
+func (h *hamiltonStrategy) Next(g *Game) grid.Direction {
+	if g.Topology != grid.Square || len(g.Walls) > 0 || len(g.Portals) > 0 || len(g.Hazards) > 0 ||
+		g.Width%2 == 1 && g.Height%2 == 1 {
+		return greedyStrategy{}.Next(g)
+	}
+	h.fit(g.Width, g.Height)
+	head := g.Head()
+	i, ok := h.order[head]
+	if !ok {
+		return stall(g, g.Snake, nil)
+	}
+	aligned := h.aligned(g.Body, h.way)
+	if !aligned && h.aligned(g.Body, -h.way) {
+		h.way, aligned = -h.way, true
+	}
+	if !aligned && g.Neighbor(head, g.Dir.Opposite()) == h.cycle[h.next(i)] {
+		// the snake isn't on the cycle yet, e.g. right after it's reset. The way round that
+		// doesn't reverse it is taken, and the body lines up behind the head as it goes.
+		h.way = -h.way
+	}
+	target := h.cycle[h.next(i)]
+	dir := g.directionTo(head, target)
+	if aligned {
+		// the cycle is safe to follow, it only ends in the body once the board is full
+		return dir
+	}
+	if dir != g.Dir.Opposite() && g.newBoard(g.Body).enterable(target, 1) {
+		return dir
+	}
+	return stall(g, g.Snake, nil)
+}
@@ -0,0 +1,28 @@
+package engine
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestHamiltonFillsEvenBoards(t *testing.T) {
+	for _, size := range [][2]int{{14, 14}, {10, 7}, {7, 10}, {6, 6}, {16, 9}} {
+		for _, wrap := range []bool{false, true} {
+			for seed := int64(0); seed < 10; seed++ {
+				g := New(rand.New(rand.NewSource(seed)), 1)
+				g.Wrap = wrap
+				g.Load(mustLevel(NewLevel("", size[0], size[1], nil, nil, nil, nil)))
+				strategy := newHamiltonStrategy()
+				cells := size[0] * size[1]
+				// following the cycle eats an apple at least every lap
+				for steps := 0; steps < cells*cells && g.Alive && !g.Won; steps++ {
+					g.Step(strategy.Next(g))
+				}
+				if !g.Won {
+					t.Errorf("%dx%d board, wrap %v, seed %d: the snake is %d cells long, alive %v, want %d",
+						size[0], size[1], wrap, seed, len(g.Body), g.Alive, cells)
+				}
+			}
+		}
+	}
+}
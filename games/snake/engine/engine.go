@@ -0,0 +1,462 @@
+// Package engine contains the rules of snake without any rendering, so games can run headless
+package engine
+
+import (
+	"math/rand"
+
+	"github.com/miluchen/games-in-go/games/grid"
+)
+
+const (
+	Width     = 15 // width of the default grid as in number of units
+	Height    = 15 // height of the default grid as in number of units
+	HexWidth  = 16 // width of the hex grid in columns
+	HexHeight = 14 // height of the hex grid in cells
+)
+
+// Cause is what killed the snake
+type Cause int
+
+const (
+	None      Cause = iota // the snake hasn't died
+	Wall                   // the snake ran into the wall
+	Self                   // the snake ran into its own body
+	Other                  // the snake ran into another snake's body
+	HeadOn                 // the snake met another snake head-on
+	HazardHit              // the snake was hit by a hazard
+)
+
+var causeNames = []string{None: "none", Wall: "wall", Self: "self", Other: "other", HeadOn: "head-on", HazardHit: "hazard"}
+
+func (c Cause) String() string {
+	return causeNames[c]
+}
+
+// Snake is one snake on the grid
+type Snake struct {
+	Alive bool           // whether the snake is still alive
+	Dir   grid.Direction // snake moving direction
+	Body  []grid.Point   // the coordinates of the whole snake, the head is the last one, empty once it turned into food
+	Score int            // one point per apple eaten, plus what the items taken are worth
+	Eaten int            // number of apples eaten, golden ones aside so they don't count toward a level
+	Cause Cause          // what killed the snake
+	Ghost int            // steps the snake can still pass through its own body, once
+}
+
+// Head returns the cell of the snake's head
+func (s *Snake) Head() grid.Point {
+	return s.Body[len(s.Body)-1]
+}
+
+// Game is the snakes and the apples on the grid. The first snake is embedded, so a single player
+// game reads like it has one snake only.
+type Game struct {
+	*Snake
+	*Level              // board the snakes play on
+	Snakes []*Snake     // all snakes, they all move at the same time
+	Apples []grid.Point // positions of the apples, an apple eaten comes back somewhere else
+	Food   []grid.Point // food left by dead snakes, it's eaten like an apple but doesn't come back
+	Feed   bool         // whether dead snakes turn into food
+	Wrap   bool         // whether the grid has no wall, a snake leaving it on one side comes back on the other
+	Won    bool         // whether the snakes fill the grid
+
+	PowerUps   bool       // whether special items appear next to the apples
+	Items      []Item     // special items on the board
+	Slow       int        // steps the game is still slowed down for
+	Hazards    []Hazard   // the level's hazards where they are now
+	rand       *rand.Rand // source of the apple positions
+	appleCount int        // apples on the board at once
+	appleSeed  int64      // seed the apples are drawn from, when seeded is set
+	seeded     bool       // whether each apple is drawn from appleSeed instead of rand
+	served     int        // apples drawn from appleSeed so far
+}
+
+// New creates a game with the given number of snakes, apples are placed with rng
+func New(rng *rand.Rand, snakes int) *Game {
+	g := &Game{Level: DefaultLevel, rand: rng, appleCount: 1}
+	for i := 0; i < snakes; i++ {
+		g.Snakes = append(g.Snakes, &Snake{Alive: true})
+	}
+	g.Snake = g.Snakes[0]
+	g.Reset()
+	g.generateApples()
+	return g
+}
+
+// Load puts the snakes on level, back to their start positions. The scores are kept.
+func (g *Game) Load(level *Level) {
+	g.Level = level
+	g.Food = nil
+	g.Items = nil
+	g.Apples = nil
+	g.Reset()
+	g.generateApples()
+}
+
+// Reset puts the snakes and the hazards back to their start positions, the scores and the apples
+// are kept.
+// Snakes start where the level says. Otherwise they start on evenly spaced rows, from the left
+// side heading east and from the right side heading west in turns, so a single snake starts in
+// the middle row.
+func (g *Game) Reset() {
+	g.Hazards = append([]Hazard(nil), g.Level.Hazards...)
+	for i, s := range g.Snakes {
+		start := g.defaultStart(i)
+		if i < len(g.Starts) {
+			start = g.Starts[i]
+		}
+		s.Dir = start.Dir
+		s.Body = g.body(start)
+	}
+}
+
+// defaultStart returns where snake i starts on a level that doesn't say. Hex cells have no east or
+// west, so there they start on evenly spaced columns instead, from the bottom heading north and
+// from the top heading south in turns.
+func (g *Game) defaultStart(i int) Start {
+	if g.Topology == grid.Hex {
+		x := (i + 1) * g.Width / (len(g.Snakes) + 1)
+		if i%2 == 0 {
+			return Start{Head: grid.Point{X: x, Y: startLength - 1}, Dir: grid.North}
+		}
+		return Start{Head: grid.Point{X: x, Y: g.Height - startLength}, Dir: grid.South}
+	}
+	y := (i + 1) * g.Height / (len(g.Snakes) + 1)
+	if i%2 == 0 {
+		return Start{Head: grid.Point{X: startLength - 1, Y: y}, Dir: grid.East}
+	}
+	return Start{Head: grid.Point{X: g.Width - startLength, Y: y}, Dir: grid.West}
+}
+
+// Step moves every living snake one cell, snake i turns to actions[i] first if possible.
+// Snakes without an action keep their direction.
+func (g *Game) Step(actions ...grid.Direction) {
+	if g.PowerUps {
+		g.age()
+	}
+	// change direction and advance the heads
+	next := make([]grid.Point, len(g.Snakes))
+	for i, s := range g.Snakes {
+		if !s.Alive {
+			continue
+		}
+		if i < len(actions) {
+			s.Dir = g.turn(s.Dir, actions[i])
+		}
+		next[i] = g.Neighbor(s.Head(), s.Dir)
+	}
+	// the hazards move along with the snakes, then collisions are checked against the snakes as
+	// they were before the step, so no snake can enter the cell a tail is leaving
+	before := g.moveHazards()
+	for i, s := range g.Snakes {
+		if !s.Alive {
+			continue
+		}
+		s.Cause = g.collide(i, next)
+		if s.Cause == None && g.hit(s, next[i], before) {
+			s.Cause = HazardHit
+		}
+	}
+	grew := false // whether a snake grew on an apple, then the board may be full
+	for i, s := range g.Snakes {
+		if !s.Alive {
+			continue
+		}
+		if s.Cause != None {
+			s.Alive = false
+			if g.Feed {
+				g.Food = append(g.Food, s.Body...)
+				s.Body = nil
+			}
+			continue
+		}
+		if s.Ghost > 0 && s.occupies(next[i]) {
+			// the snake passes through its body, which uses the ghost up
+			s.Ghost = 0
+		}
+		s.Body = append(s.Body, next[i])
+		// if an apple or food is eaten, the snake grows
+		switch {
+		case g.eatApple(next[i]):
+			grew = true
+		case g.eatFood(next[i]):
+		default:
+			// items score and change the snake on their own, a golden apple makes it grow too
+			taken, golden := g.takeItem(s, next[i])
+			grew = grew || golden
+			if !taken {
+				s.Body = s.Body[1:]
+			}
+			continue
+		}
+		s.Score += 1
+		s.Eaten++
+	}
+	if grew && g.cells() == g.occupied() {
+		// the snakes fill the board, there is no room for another apple
+		g.Won = true
+	} else {
+		// apples left out for lack of room come back once an item expires or is taken
+		g.generateApples()
+	}
+	if g.PowerUps && !g.Won {
+		g.spawnItems()
+	}
+}
+
+// occupies tells whether p is part of the snake
+func (s *Snake) occupies(p grid.Point) bool {
+	for _, pos := range s.Body {
+		if pos == p {
+			return true
+		}
+	}
+	return false
+}
+
+// edible tells whether there is an apple or food on p
+func (g *Game) edible(p grid.Point) bool {
+	if g.IsApple(p) {
+		return true
+	}
+	for _, food := range g.Food {
+		if food == p {
+			return true
+		}
+	}
+	return false
+}
+
+// IsApple tells whether there is an apple on p
+func (g *Game) IsApple(p grid.Point) bool {
+	for _, apple := range g.Apples {
+		if apple == p {
+			return true
+		}
+	}
+	return false
+}
+
+// NearestApple returns the apple closest to p, counting steps on a grid without walls inside.
+// It returns p itself if there is no apple, which only happens once the board is full.
+func (g *Game) NearestApple(p grid.Point) grid.Point {
+	nearest, best := p, -1
+	for _, apple := range g.Apples {
+		if d := g.Distance(p, apple); best == -1 || d < best {
+			nearest, best = apple, d
+		}
+	}
+	return nearest
+}
+
+// eatApple removes the apple on p, it reports whether there was one
+func (g *Game) eatApple(p grid.Point) bool {
+	for i, apple := range g.Apples {
+		if apple == p {
+			g.Apples = append(g.Apples[:i], g.Apples[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// eatFood removes the food on p, it reports whether there was any
+func (g *Game) eatFood(p grid.Point) bool {
+	for i, food := range g.Food {
+		if food == p {
+			g.Food = append(g.Food[:i], g.Food[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Neighbor returns the cell next to p in direction dir, or the cell past the partner portal when
+// that one is a portal. It is off the grid past the wall unless the grid wraps
+func (g *Game) Neighbor(p grid.Point, dir grid.Direction) grid.Point {
+	return g.step(p, dir, g.Wrap)
+}
+
+// Distance returns the number of steps between a and b on a grid without walls or portals inside
+func (g *Game) Distance(a, b grid.Point) int {
+	return g.distance(a, b, g.Wrap)
+}
+
+// collide returns what kills snake i when the heads move to next
+func (g *Game) collide(i int, next []grid.Point) Cause {
+	// check the snake is not out of bound or in a wall
+	if !next[i].In(g.Width, g.Height) || g.Wall(next[i]) {
+		return Wall
+	}
+	// check the snake is not colliding with itself or others
+	for j, s := range g.Snakes {
+		for _, pos := range s.Body {
+			if pos != next[i] {
+				continue
+			}
+			if j == i {
+				if s.Ghost > 0 {
+					break
+				}
+				return Self
+			}
+			return Other
+		}
+		if j != i && s.Alive && next[j] == next[i] {
+			return HeadOn
+		}
+	}
+	return None
+}
+
+// Winner returns the snake that won a game between several snakes, or -1 for a draw. The last snake
+// alive wins, otherwise the higher score among the snakes that lasted longest wins.
+func (g *Game) Winner() int {
+	var candidates []int
+	for i, s := range g.Snakes {
+		if s.Alive {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		// they all died in the same step
+		for i := range g.Snakes {
+			candidates = append(candidates, i)
+		}
+	}
+	best, draw := candidates[0], false
+	for _, i := range candidates[1:] {
+		switch {
+		case g.Snakes[i].Score > g.Snakes[best].Score:
+			best, draw = i, false
+		case g.Snakes[i].Score == g.Snakes[best].Score:
+			draw = true
+		}
+	}
+	if draw {
+		return -1
+	}
+	return best
+}
+
+// Survivors returns the number of snakes alive
+func (g *Game) Survivors() int {
+	count := 0
+	for _, s := range g.Snakes {
+		if s.Alive {
+			count++
+		}
+	}
+	return count
+}
+
+// cells returns the number of cells snakes can be on now, the level's less the ones hazards are on
+func (g *Game) cells() int {
+	on := make(map[grid.Point]bool)
+	for _, h := range g.Hazards {
+		on[h.At] = true
+	}
+	return g.Level.cells() - len(on)
+}
+
+// occupied returns the number of cells taken by snakes
+func (g *Game) occupied() int {
+	count := 0
+	for _, s := range g.Snakes {
+		count += len(s.Body)
+	}
+	return count
+}
+
+// SetAppleCount sets the number of apples on the board at once, at least 1. The apples missing
+// are added right away, the ones too many are taken away.
+func (g *Game) SetAppleCount(count int) {
+	g.appleCount = max(count, 1)
+	if len(g.Apples) > g.appleCount {
+		g.Apples = g.Apples[:g.appleCount]
+	}
+	g.generateApples()
+}
+
+// generateApples adds apples until there are appleCount of them, as long as there are free cells
+// left. Otherwise food and items take every free cell, and apples stay eaten until they leave.
+// The free cells are never overcounted, a hazard passing over an apple only counts them short, so
+// an apple drawn always finds a cell.
+func (g *Game) generateApples() {
+	for len(g.Apples) < g.appleCount && g.cells()-g.occupied() > len(g.Food)+len(g.Items)+len(g.Apples) {
+		g.generateApple()
+	}
+}
+
+// SetAppleSeed makes the apples come from seed alone: the n-th apple of the game is drawn from its
+// own stream of numbers, so every game played with the same seed gets the same apples whatever the
+// snake does. An apple drawn on a taken cell is drawn again from the same stream.
+func (g *Game) SetAppleSeed(seed int64) {
+	g.appleSeed = seed
+	g.seeded = true
+	g.served = 0
+}
+
+// appleRand returns the numbers the next apple is drawn from
+func (g *Game) appleRand() *rand.Rand {
+	if !g.seeded {
+		return g.rand
+	}
+	g.served++
+	// the streams are mixed apart from those of the maze levels of the same seed
+	return rand.New(rand.NewSource(int64(uint64(g.appleSeed)*0xbf58476d1ce4e5b9 + uint64(g.served))))
+}
+
+// generate an apple randomly, in one of the level's apple zones if it has any free
+func (g *Game) generateApple() {
+	rng := g.appleRand()
+	if len(g.Zones) > 0 {
+		var free []grid.Point
+		for _, p := range g.Zones {
+			if !g.taken(p) {
+				free = append(free, p)
+			}
+		}
+		if len(free) > 0 {
+			g.Apples = append(g.Apples, free[rng.Intn(len(free))])
+			return
+		}
+		// the zones are full, the apple may appear anywhere
+	}
+	for {
+		x := rng.Intn(g.Width)
+		y := rng.Intn(g.Height)
+		if p := (grid.Point{X: x, Y: y}); !g.taken(p) {
+			g.Apples = append(g.Apples, p)
+			break
+		}
+	}
+}
+
+// taken tells whether p is a wall, part of a snake, an apple, food or an item
+func (g *Game) taken(p grid.Point) bool {
+	if g.Wall(p) || g.isPortal(p) || g.hazardAt(p) {
+		return true
+	}
+	for _, s := range g.Snakes {
+		for _, pos := range s.Body {
+			if pos == p {
+				return true
+			}
+		}
+	}
+	if g.IsApple(p) {
+		return true
+	}
+	for _, pos := range g.Food {
+		if pos == p {
+			return true
+		}
+	}
+	for _, item := range g.Items {
+		if item.At == p {
+			return true
+		}
+	}
+	return false
+}
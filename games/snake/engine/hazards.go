@@ -0,0 +1,155 @@
+// hazards.go contains the hazards of a level, obstacles moving on their own that kill the snakes
+// they touch
+
+package engine
+
+import (
+	"fmt"
+
+	"github.com/miluchen/games-in-go/games/grid"
+)
+
+// hazardEvery is the number of steps between two moves of a hazard unless the level says otherwise
+const hazardEvery = 2
+
+// hazardHorizon is how many steps ahead path finding knows where the hazards are
+const hazardHorizon = 64
+
+// hazardMargin is how many more steps path finding keeps a cell taken, in case the snake grows
+const hazardMargin = 4
+
+// Hazard moves on its own, once every few steps. A bouncing hazard goes straight and turns back
+// when a wall, a portal or the side of the board is in its way. A patrolling one goes along its
+// path to the end and back, or round and round when the path ends next to where it starts.
+type Hazard struct {
+	At    grid.Point     // where it is
+	Dir   grid.Direction // where a bouncing hazard heads
+	Path  []grid.Point   // cells a patrolling hazard goes through in order, empty for a bouncing one
+	Loop  bool           // whether a patrol goes round its path instead of back and forth
+	Every int            // steps between two moves
+
+	pos  int  // index in Path of the cell it's on
+	back bool // whether a patrol goes along its path backwards
+	wait int  // steps before it moves again
+}
+
+// AddHazard adds a hazard to the level, it checks that it moves on free cells and doesn't start on
+// a snake or right in front of one
+func (l *Level) AddHazard(h Hazard) error {
+	if h.Every < 1 {
+		return fmt.Errorf("hazard at %s must move at least once every step", l.at(h.At))
+	}
+	if len(h.Path) == 0 && !l.hasDirection(h.Dir) {
+		return fmt.Errorf("hazard at %s heads where the cells have no neighbor", l.at(h.At))
+	}
+	if len(h.Path) > 0 {
+		if h.At != h.Path[0] {
+			return fmt.Errorf("hazard at %s doesn't start on its path", l.at(h.At))
+		}
+		if len(h.Path) < 2 {
+			return fmt.Errorf("hazard at %s has a path of one cell", l.at(h.At))
+		}
+		for i, p := range h.Path {
+			if i > 0 && !l.adjacent(h.Path[i-1], p) {
+				return fmt.Errorf("the path of the hazard at %s jumps from %s to %s", l.at(h.At), l.at(h.Path[i-1]), l.at(p))
+			}
+		}
+		if h.Loop && !l.adjacent(h.Path[len(h.Path)-1], h.Path[0]) {
+			return fmt.Errorf("the path of the hazard at %s doesn't end next to its start", l.at(h.At))
+		}
+	}
+	for _, p := range append([]grid.Point{h.At}, h.Path...) {
+		if !l.open(p) {
+			return fmt.Errorf("hazard at %s goes through %s, which is not an empty cell", l.at(h.At), l.at(p))
+		}
+	}
+	for i, start := range l.Starts {
+		for _, p := range append(l.body(start), l.Topology.Move(start.Head, start.Dir)) {
+			if p == h.At {
+				return fmt.Errorf("hazard at %s is on snake %d or right in front of it", l.at(h.At), i+1)
+			}
+		}
+	}
+	l.Hazards = append(l.Hazards, h)
+	return nil
+}
+
+// open tells whether p is a cell of the board a hazard can go through
+func (l *Level) open(p grid.Point) bool {
+	return p.In(l.Width, l.Height) && !l.walls[l.index(p)] && !l.isPortal(p)
+}
+
+// adjacent tells whether a and b are next to each other on the board
+func (l *Level) adjacent(a, b grid.Point) bool {
+	for _, dir := range l.Directions() {
+		if l.Topology.Move(a, dir) == b {
+			return true
+		}
+	}
+	return false
+}
+
+// moved returns h after one more step of the game, it moves if its turn has come
+func (l *Level) moved(h Hazard) Hazard {
+	if h.wait > 0 {
+		h.wait--
+		return h
+	}
+	h.wait = h.Every - 1
+	switch {
+	case len(h.Path) == 0:
+		if !l.open(l.Topology.Move(h.At, h.Dir)) {
+			h.Dir = h.Dir.Opposite()
+		}
+		// a hazard stuck both ways stays where it is
+		if n := l.Topology.Move(h.At, h.Dir); l.open(n) {
+			h.At = n
+		}
+		return h
+	case h.Loop:
+		h.pos = (h.pos + 1) % len(h.Path)
+	case h.back && h.pos == 0, !h.back && h.pos == len(h.Path)-1:
+		h.back = !h.back
+		fallthrough
+	default:
+		if h.back {
+			h.pos--
+		} else {
+			h.pos++
+		}
+	}
+	h.At = h.Path[h.pos]
+	return h
+}
+
+// moveHazards moves the hazards one step, it returns where they were
+func (g *Game) moveHazards() []grid.Point {
+	before := make([]grid.Point, len(g.Hazards))
+	for i, h := range g.Hazards {
+		before[i] = h.At
+		g.Hazards[i] = g.moved(h)
+	}
+	return before
+}
+
+// hazardAt tells whether a hazard is on p
+func (g *Game) hazardAt(p grid.Point) bool {
+	for _, h := range g.Hazards {
+		if h.At == p {
+			return true
+		}
+	}
+	return false
+}
+
+// hit tells whether snake s gets hit by a hazard when its head moves to next, the hazards having
+// moved from before. The head may run into a hazard or cross one going the other way, and a hazard
+// may run into the body, the tail included since nothing enters the cell a tail is leaving.
+func (g *Game) hit(s *Snake, next grid.Point, before []grid.Point) bool {
+	for i, h := range g.Hazards {
+		if h.At == next || before[i] == next && h.At == s.Head() || s.occupies(h.At) {
+			return true
+		}
+	}
+	return false
+}
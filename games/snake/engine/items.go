@@ -0,0 +1,148 @@
+// items.go contains the special items that appear next to the apples when power-ups are on
+
+package engine
+
+import "github.com/miluchen/games-in-go/games/grid"
+
+// Kind is a kind of special item
+type Kind int
+
+const (
+	Golden Kind = iota // an apple worth more, the snake grows by one
+	Slow               // slows the game down for a while
+	Ghost              // lets the snake pass through its own body once, for a while
+	Shrink             // takes segments off the tail
+	Poison             // takes segments off the tail and costs score
+)
+
+// Kinds are the kinds of items in the order they're drawn on the HUD
+var Kinds = []Kind{Golden, Slow, Ghost, Shrink, Poison}
+
+var kindNames = map[Kind]string{
+	Golden: "Golden",
+	Slow:   "Slow",
+	Ghost:  "Ghost",
+	Shrink: "Shrink",
+	Poison: "Poison",
+}
+
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+// itemRule is how an item of a kind appears and what it does, times are counted in steps
+type itemRule struct {
+	every    int // steps between two items of the kind on average, there is one at a time
+	minScore int // score the player needs before the kind appears
+	lifetime int // steps the item stays on the board before it disappears
+	duration int // steps its effect lasts
+	segments int // segments it takes off the tail
+	score    int // score it's worth, negative for a penalty
+}
+
+var itemRules = map[Kind]itemRule{
+	Golden: {every: 60, lifetime: 40, score: 5},
+	Slow:   {every: 120, minScore: 5, lifetime: 60, duration: 50, score: 1},
+	Ghost:  {every: 150, minScore: 10, lifetime: 60, duration: 80, score: 1},
+	Shrink: {every: 150, minScore: 15, lifetime: 60, segments: 3, score: 1},
+	Poison: {every: 100, minScore: 5, lifetime: 80, segments: 2, score: -3},
+}
+
+// Item is a special item on the board
+type Item struct {
+	Kind Kind
+	At   grid.Point
+	Left int // steps before it disappears
+}
+
+// Effect returns the number of steps the effect of kind k is still on for snake s, slow-motion
+// is on for every snake
+func (g *Game) Effect(s *Snake, k Kind) int {
+	switch k {
+	case Slow:
+		return g.Slow
+	case Ghost:
+		return s.Ghost
+	}
+	return 0
+}
+
+// age counts down the items and the effects, it's called at the start of every step
+func (g *Game) age() {
+	if g.Slow > 0 {
+		g.Slow--
+	}
+	for _, s := range g.Snakes {
+		if s.Ghost > 0 {
+			s.Ghost--
+		}
+	}
+	items := g.Items[:0]
+	for _, item := range g.Items {
+		item.Left--
+		if item.Left > 0 {
+			items = append(items, item)
+		}
+	}
+	g.Items = items
+}
+
+// spawnItems may place an item of each kind not on the board yet, it's called at the end of every step
+func (g *Game) spawnItems() {
+	for _, k := range Kinds {
+		rule := itemRules[k]
+		if g.Score < rule.minScore || g.hasItem(k) || g.rand.Intn(rule.every) != 0 {
+			continue
+		}
+		var free []grid.Point
+		for y := 0; y < g.Height; y++ {
+			for x := 0; x < g.Width; x++ {
+				if p := (grid.Point{X: x, Y: y}); !g.taken(p) {
+					free = append(free, p)
+				}
+			}
+		}
+		if len(free) == 0 {
+			return
+		}
+		g.Items = append(g.Items, Item{Kind: k, At: free[g.rand.Intn(len(free))], Left: rule.lifetime})
+	}
+}
+
+func (g *Game) hasItem(k Kind) bool {
+	for _, item := range g.Items {
+		if item.Kind == k {
+			return true
+		}
+	}
+	return false
+}
+
+// takeItem lets s take the item on p, the head having moved there already. It reports whether
+// there was an item, and whether the snake grew. A golden apple makes the snake grow, any other
+// item lets the tail move on.
+func (g *Game) takeItem(s *Snake, p grid.Point) (bool, bool) {
+	for i, item := range g.Items {
+		if item.At != p {
+			continue
+		}
+		g.Items = append(g.Items[:i], g.Items[i+1:]...)
+		rule := itemRules[item.Kind]
+		if item.Kind != Golden {
+			s.Body = s.Body[1:]
+		}
+		switch item.Kind {
+		case Slow:
+			g.Slow = rule.duration
+		case Ghost:
+			s.Ghost = rule.duration
+		}
+		// the snake never gets shorter than it starts
+		if cut := min(rule.segments, len(s.Body)-startLength); cut > 0 {
+			s.Body = s.Body[cut:]
+		}
+		s.Score = max(s.Score+rule.score, 0)
+		return true, item.Kind == Golden
+	}
+	return false, false
+}
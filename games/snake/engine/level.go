@@ -0,0 +1,474 @@
+// level.go describes the boards snakes play on and parses them from text files
+
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/miluchen/games-in-go/games/grid"
+)
+
+// level file characters, each line of a board is a row of cells from the top
+const (
+	emptyChar     = '.'
+	wallChar      = '#'
+	zoneChar      = '*'         // empty cell where apples may appear
+	portalChars   = "123456789" // the two cells marked with the same digit are a pair of portals
+	boardChars    = ".#*^>v<-|" + portalChars
+	titlePrefix   = "title:"
+	patrolPrefix  = "patrol:"
+	everyWord     = "every"
+	commentPrefix = ";"
+	minLevelSize  = 5  // min width or height of a level
+	maxLevelSize  = 40 // max width or height of a level
+	startLength   = 3  // length of a snake when it starts
+)
+
+// startChars are the heads of starting snakes by the direction they head to
+var startChars = map[rune]grid.Direction{'^': grid.North, '>': grid.East, 'v': grid.South, '<': grid.West}
+
+// bouncerChars are the bouncing hazards by the direction they head to first
+var bouncerChars = map[rune]grid.Direction{'-': grid.East, '|': grid.North}
+
+// Level is the board a game is played on
+type Level struct {
+	Title    string
+	Topology grid.Topology // square or hex cells
+	Width    int
+	Height   int
+	Walls    []grid.Point // cells blocked inside the board, the board is surrounded by a wall anyway
+	Starts   []Start      // where the snakes start, in order, snakes without one start as on an empty board
+	Zones    []grid.Point // cells where apples may appear, anywhere free if empty
+	// pairs of portals, a snake entering one comes out of the other heading the same way
+	Portals [][2]grid.Point
+	Hazards []Hazard // hazards where they start
+
+	walls   []bool                    // whether each cell is a wall, row-major from the bottom row
+	portals map[grid.Point]grid.Point // the partner of each portal
+}
+
+// Start is where a snake starts, its body lies behind its head
+type Start struct {
+	Head grid.Point
+	Dir  grid.Direction
+}
+
+// DefaultLevel is an empty board
+var DefaultLevel = mustLevel(NewLevel("", Width, Height, nil, nil, nil, nil))
+
+// HexLevel is an empty board of hex cells, about as big as the default one
+var HexLevel = mustLevel(NewHexLevel("", HexWidth, HexHeight, nil, nil, nil, nil))
+
+// NewLevel creates a level of square cells and checks that its cells are on the board, that the
+// snakes fit and that apples can appear
+func NewLevel(title string, width, height int, walls []grid.Point, starts []Start, zones []grid.Point,
+	portals [][2]grid.Point) (*Level, error) {
+	return newLevel(grid.Square, title, width, height, walls, starts, zones, portals)
+}
+
+// NewHexLevel creates a level of hex cells like NewLevel does. Its width must be even, so the
+// columns on both sides fit together when the board wraps.
+func NewHexLevel(title string, width, height int, walls []grid.Point, starts []Start, zones []grid.Point,
+	portals [][2]grid.Point) (*Level, error) {
+	if width%2 != 0 {
+		return nil, fmt.Errorf("hex level is %d columns wide, it must be even", width)
+	}
+	return newLevel(grid.Hex, title, width, height, walls, starts, zones, portals)
+}
+
+func newLevel(topology grid.Topology, title string, width, height int, walls []grid.Point, starts []Start,
+	zones []grid.Point, portals [][2]grid.Point) (*Level, error) {
+	if width < minLevelSize || height < minLevelSize || width > maxLevelSize || height > maxLevelSize {
+		return nil, fmt.Errorf("level is %dx%d, the size must be between %dx%d and %dx%d",
+			width, height, minLevelSize, minLevelSize, maxLevelSize, maxLevelSize)
+	}
+	l := &Level{Title: title, Topology: topology, Width: width, Height: height, Walls: walls, Starts: starts,
+		Zones: zones, Portals: portals, walls: make([]bool, width*height), portals: make(map[grid.Point]grid.Point)}
+	for _, p := range walls {
+		if !p.In(width, height) {
+			return nil, fmt.Errorf("wall at %s is off the board", l.at(p))
+		}
+		l.walls[l.index(p)] = true
+	}
+	for _, pair := range portals {
+		for i, p := range pair {
+			switch {
+			case !p.In(width, height) || l.walls[l.index(p)]:
+				return nil, fmt.Errorf("portal at %s is not an empty cell", l.at(p))
+			case l.isPortal(p):
+				return nil, fmt.Errorf("portal at %s is already a portal", l.at(p))
+			}
+			l.portals[p] = pair[1-i]
+		}
+	}
+	// a snake never stays on a portal, so it can't come out of one onto another
+	for p := range l.portals {
+		for _, dir := range l.Directions() {
+			if l.isPortal(l.Topology.Move(p, dir)) {
+				return nil, fmt.Errorf("portal at %s is next to another portal", l.at(p))
+			}
+		}
+	}
+	taken := make(map[grid.Point]bool)
+	for i, start := range starts {
+		if !l.hasDirection(start.Dir) {
+			return nil, fmt.Errorf("snake %d starting at %s heads where the cells have no neighbor", i+1, l.at(start.Head))
+		}
+		for _, p := range l.body(start) {
+			switch {
+			case !p.In(width, height):
+				return nil, fmt.Errorf("snake %d starting at %s doesn't fit, its body runs off the board", i+1, l.at(start.Head))
+			case l.walls[l.index(p)]:
+				return nil, fmt.Errorf("snake %d starting at %s doesn't fit, its body runs into a wall", i+1, l.at(start.Head))
+			case l.isPortal(p):
+				return nil, fmt.Errorf("snake %d starting at %s doesn't fit, its body lies on a portal", i+1, l.at(start.Head))
+			case taken[p]:
+				return nil, fmt.Errorf("snake %d starting at %s runs into another snake", i+1, l.at(start.Head))
+			}
+			taken[p] = true
+		}
+		// a snake facing a wall dies on its first step
+		if n := l.step(start.Head, start.Dir, false); !n.In(width, height) || l.walls[l.index(n)] {
+			return nil, fmt.Errorf("snake %d starting at %s faces a wall", i+1, l.at(start.Head))
+		}
+	}
+	for _, p := range zones {
+		if !p.In(width, height) || l.walls[l.index(p)] || l.isPortal(p) {
+			return nil, fmt.Errorf("apple zone at %s is not an empty cell", l.at(p))
+		}
+	}
+	if len(zones) == 0 && len(walls)+len(l.portals)+len(taken) >= width*height {
+		return nil, fmt.Errorf("there is no room for the apple")
+	}
+	if len(starts) > 0 {
+		if p, ok := l.unreachable(starts[0].Head); ok {
+			return nil, fmt.Errorf("%s can't be reached by the snakes, wall it or open it up", l.at(p))
+		}
+	}
+	return l, nil
+}
+
+func mustLevel(l *Level, err error) *Level {
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
+
+// at tells where p is as a level file shows it, rows count from the top
+func (l *Level) at(p grid.Point) string {
+	return fmt.Sprintf("row %d, column %d", l.Height-p.Y, p.X+1)
+}
+
+func (l *Level) index(p grid.Point) int {
+	return p.Y*l.Width + p.X
+}
+
+// Wall tells whether p is a wall inside the board
+func (l *Level) Wall(p grid.Point) bool {
+	return p.In(l.Width, l.Height) && l.walls[l.index(p)]
+}
+
+// isPortal tells whether p is a portal
+func (l *Level) isPortal(p grid.Point) bool {
+	_, ok := l.portals[p]
+	return ok
+}
+
+// step returns the cell a snake on p heading dir moves to. Stepping on a portal takes it out of
+// the partner portal, on the cell past it. The cell is off the board past the wall unless wrap
+// joins the opposite sides.
+func (l *Level) step(p grid.Point, dir grid.Direction, wrap bool) grid.Point {
+	n := l.Topology.Move(p, dir)
+	if wrap {
+		n = n.Wrap(l.Width, l.Height)
+	}
+	if partner, ok := l.portals[n]; ok {
+		n = l.Topology.Move(partner, dir)
+		if wrap {
+			n = n.Wrap(l.Width, l.Height)
+		}
+	}
+	return n
+}
+
+// cells returns the number of cells snakes can be on, portals are only passed through
+func (l *Level) cells() int {
+	count := 0
+	for _, wall := range l.walls {
+		if !wall {
+			count++
+		}
+	}
+	return count - len(l.portals)
+}
+
+// unreachable returns a cell that isn't a wall and can't be reached from start, apples appearing
+// there could never be eaten. The sides of the board are taken as walls, so a level is playable
+// whether the board wraps or not.
+func (l *Level) unreachable(start grid.Point) (grid.Point, bool) {
+	visited := make([]bool, len(l.walls))
+	visited[l.index(start)] = true
+	queue := []grid.Point{start}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		for _, dir := range l.Directions() {
+			n := l.step(p, dir, false)
+			if n.In(l.Width, l.Height) && !l.walls[l.index(n)] && !visited[l.index(n)] {
+				visited[l.index(n)] = true
+				queue = append(queue, n)
+			}
+		}
+	}
+	for i, wall := range l.walls {
+		p := grid.Point{X: i % l.Width, Y: i / l.Width}
+		if !wall && !visited[i] && !l.isPortal(p) {
+			return p, true
+		}
+	}
+	return grid.Point{}, false
+}
+
+// Directions returns the directions a snake can head to on the level's cells
+func (l *Level) Directions() []grid.Direction {
+	return l.Topology.Directions()
+}
+
+// hasDirection tells whether the level's cells have a neighbor in direction dir
+func (l *Level) hasDirection(dir grid.Direction) bool {
+	for _, d := range l.Directions() {
+		if d == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// turn returns the direction of a snake heading dir after action is requested, actions the cells
+// have no neighbor in are ignored like reversing is
+func (l *Level) turn(dir, action grid.Direction) grid.Direction {
+	if !l.hasDirection(action) {
+		return dir
+	}
+	return grid.ChangeDirection(dir, action)
+}
+
+// distance returns the number of steps between a and b on the board without walls inside, which
+// may go across the sides when the board wraps
+func (l *Level) distance(a, b grid.Point, wrap bool) int {
+	best := l.Topology.Distance(a, b)
+	if !wrap {
+		return best
+	}
+	for _, dx := range []int{-l.Width, 0, l.Width} {
+		for _, dy := range []int{-l.Height, 0, l.Height} {
+			best = min(best, l.Topology.Distance(a, grid.Point{X: b.X + dx, Y: b.Y + dy}))
+		}
+	}
+	return best
+}
+
+// body returns the cells of a snake starting with start, the head last
+func (l *Level) body(start Start) []grid.Point {
+	body := make([]grid.Point, startLength)
+	p := start.Head
+	for i := startLength - 1; i >= 0; i-- {
+		body[i] = p
+		p = l.Topology.Move(p, start.Dir.Opposite())
+	}
+	return body
+}
+
+// ParseLevels reads a collection of levels. A board is a block of lines, one per row from the top,
+// made of '.' for empty cells, '#' for walls, '*' for empty cells where apples may appear and
+// '^', '>', 'v' or '<' for the head of a starting snake, heading that way with its body behind it.
+// A digit from '1' to '9' marks a portal, each digit used marks exactly two cells, which are a pair.
+// '-' and '|' are hazards bouncing from side to side and up and down, and a "Patrol: " line after a
+// board adds a hazard going along a path on it.
+// Snakes start in the order their heads appear. Levels are separated by any other line, a comment
+// line starting with ';' right before a board is its title and a "Title: " line after it overrides it.
+func ParseLevels(r io.Reader) ([]*Level, error) {
+	var levels []*Level
+	var rows []string
+	var pendingTitle string
+	firstRow, lineNo := 0, 0
+
+	flush := func() error {
+		if len(rows) == 0 {
+			return nil
+		}
+		level, err := parseBoard(rows, pendingTitle)
+		if err != nil {
+			return fmt.Errorf("level %d (lines %d-%d): %v", len(levels)+1, firstRow, firstRow+len(rows)-1, err)
+		}
+		if level.Title == "" {
+			level.Title = fmt.Sprintf("Level %d", len(levels)+1)
+		}
+		levels = append(levels, level)
+		rows = nil
+		pendingTitle = ""
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if isBoardLine(line) {
+			if len(rows) == 0 {
+				firstRow = lineNo
+			}
+			rows = append(rows, line)
+			continue
+		}
+		if err := flush(); err != nil {
+			return nil, err
+		}
+		switch {
+		case strings.HasPrefix(strings.ToLower(line), titlePrefix):
+			title := strings.TrimSpace(line[len(titlePrefix):])
+			if len(levels) > 0 && title != "" {
+				levels[len(levels)-1].Title = title
+			}
+		case strings.HasPrefix(strings.ToLower(line), patrolPrefix):
+			if len(levels) == 0 {
+				return nil, fmt.Errorf("line %d: a patrol comes after the board it's on", lineNo)
+			}
+			if err := levels[len(levels)-1].parsePatrol(line[len(patrolPrefix):]); err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNo, err)
+			}
+		case strings.HasPrefix(line, commentPrefix):
+			pendingTitle = strings.TrimSpace(strings.TrimPrefix(line, commentPrefix))
+		case line != "":
+			return nil, fmt.Errorf("line %d: %q is neither a board row, a comment nor a title", lineNo, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	if len(levels) == 0 {
+		return nil, fmt.Errorf("no levels found")
+	}
+	return levels, nil
+}
+
+// isBoardLine reports whether the line is a row of a board
+func isBoardLine(line string) bool {
+	if line == "" {
+		return false
+	}
+	for _, c := range line {
+		if !strings.ContainsRune(boardChars, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseBoard creates the level drawn by rows, the first row is the top one
+func parseBoard(rows []string, title string) (*Level, error) {
+	width, height := len(rows[0]), len(rows)
+	var walls, zones []grid.Point
+	var starts []Start
+	var hazards []Hazard
+	pairs := make(map[rune][]grid.Point)
+	var digits []rune // digits in the order they first appear
+	for r, row := range rows {
+		if len(row) != width {
+			return nil, fmt.Errorf("row %d is %d cells wide, the first row is %d", r+1, len(row), width)
+		}
+		for c, char := range row {
+			p := grid.Point{X: c, Y: height - 1 - r}
+			switch char {
+			case wallChar:
+				walls = append(walls, p)
+			case zoneChar:
+				zones = append(zones, p)
+			case emptyChar:
+			case '-', '|':
+				hazards = append(hazards, Hazard{At: p, Dir: bouncerChars[char], Every: hazardEvery})
+			case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+				if len(pairs[char]) == 0 {
+					digits = append(digits, char)
+				}
+				pairs[char] = append(pairs[char], p)
+			default:
+				starts = append(starts, Start{Head: p, Dir: startChars[char]})
+			}
+		}
+	}
+	if len(starts) == 0 {
+		return nil, fmt.Errorf("no snake, mark where it starts with its head heading ^, >, v or <")
+	}
+	var portals [][2]grid.Point
+	for _, digit := range digits {
+		if cells := pairs[digit]; len(cells) != 2 {
+			return nil, fmt.Errorf("portal %c marks %d cells, it must mark two", digit, len(cells))
+		}
+		portals = append(portals, [2]grid.Point{pairs[digit][0], pairs[digit][1]})
+	}
+	l, err := NewLevel(title, width, height, walls, starts, zones, portals)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range hazards {
+		if err := l.AddHazard(h); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// parsePatrol adds the patrol described by a "Patrol:" line to the level. The line lists the cells
+// where the patrol turns as row,column like "3,2 3,12 9,12" and the patrol goes straight between
+// them. It may end with "every N" to move once every N steps. A patrol ending where it starts goes
+// round, any other goes back and forth.
+func (l *Level) parsePatrol(line string) error {
+	fields := strings.Fields(line)
+	every := hazardEvery
+	if n := len(fields); n >= 2 && strings.ToLower(fields[n-2]) == everyWord {
+		if _, err := fmt.Sscanf(fields[n-1], "%d", &every); err != nil {
+			return fmt.Errorf("%q is not a number of steps", fields[n-1])
+		}
+		fields = fields[:n-2]
+	}
+	if len(fields) < 2 {
+		return fmt.Errorf("a patrol needs at least two cells")
+	}
+	var path []grid.Point
+	for i, field := range fields {
+		var row, column int
+		if _, err := fmt.Sscanf(field, "%d,%d", &row, &column); err != nil {
+			return fmt.Errorf("%q is not a cell, write it as row,column", field)
+		}
+		p := grid.Point{X: column - 1, Y: l.Height - row}
+		// checked before the straight line to it is filled in, a typo could make it huge
+		if !p.In(l.Width, l.Height) {
+			return fmt.Errorf("patrol cell %s is off the board", l.at(p))
+		}
+		if i == 0 {
+			path = append(path, p)
+			continue
+		}
+		from := path[len(path)-1]
+		if from.X != p.X && from.Y != p.Y || from == p {
+			return fmt.Errorf("the patrol can't go straight from %s to %s", l.at(from), l.at(p))
+		}
+		for from != p {
+			from = grid.Point{X: from.X + sign(p.X-from.X), Y: from.Y + sign(p.Y-from.Y)}
+			path = append(path, from)
+		}
+	}
+	loop := path[len(path)-1] == path[0]
+	if loop {
+		path = path[:len(path)-1]
+	}
+	return l.AddHazard(Hazard{At: path[0], Path: path, Loop: loop, Every: every})
+}
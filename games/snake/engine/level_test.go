@@ -0,0 +1,142 @@
+package engine
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/miluchen/games-in-go/games/grid"
+)
+
+func TestParseLevels(t *testing.T) {
+	const collection = `; Corners
+#...#
+.1.*.
+..>..
+-...1
+#...#
+Patrol: 4,2 4,4 every 3
+
+.....
+.....
+..v..
+.....
+.....
+Title: Open
+Patrol: 1,1 1,2 2,2 2,1 1,1
+`
+	levels, err := ParseLevels(strings.NewReader(collection))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(levels) != 2 {
+		t.Fatalf("got %d levels, want 2", len(levels))
+	}
+	l := levels[0]
+	if l.Title != "Corners" || l.Width != 5 || l.Height != 5 {
+		t.Errorf("first level is %q %dx%d, want \"Corners\" 5x5", l.Title, l.Width, l.Height)
+	}
+	// rows are counted from the top, y from the bottom
+	walls := []grid.Point{{X: 0, Y: 4}, {X: 4, Y: 4}, {X: 0, Y: 0}, {X: 4, Y: 0}}
+	if !reflect.DeepEqual(l.Walls, walls) {
+		t.Errorf("walls are %v, want %v", l.Walls, walls)
+	}
+	if want := []Start{{Head: grid.Point{X: 2, Y: 2}, Dir: grid.East}}; !reflect.DeepEqual(l.Starts, want) {
+		t.Errorf("starts are %v, want %v", l.Starts, want)
+	}
+	if want := []grid.Point{{X: 3, Y: 3}}; !reflect.DeepEqual(l.Zones, want) {
+		t.Errorf("zones are %v, want %v", l.Zones, want)
+	}
+	if want := [][2]grid.Point{{{X: 1, Y: 3}, {X: 4, Y: 1}}}; !reflect.DeepEqual(l.Portals, want) {
+		t.Errorf("portals are %v, want %v", l.Portals, want)
+	}
+	if len(l.Hazards) != 2 {
+		t.Fatalf("got %d hazards, want 2", len(l.Hazards))
+	}
+	if h := l.Hazards[0]; h.At != (grid.Point{X: 0, Y: 1}) || h.Dir != grid.East || h.Path != nil {
+		t.Errorf("bouncing hazard is %+v, want one at 0,1 heading east", h)
+	}
+	patrol := []grid.Point{{X: 1, Y: 1}, {X: 2, Y: 1}, {X: 3, Y: 1}}
+	if h := l.Hazards[1]; !reflect.DeepEqual(h.Path, patrol) || h.Loop || h.Every != 3 {
+		t.Errorf("patrol goes along %v, loop %v, every %d, want %v back and forth every 3", h.Path, h.Loop, h.Every, patrol)
+	}
+
+	l = levels[1]
+	if l.Title != "Open" || len(l.Hazards) != 1 {
+		t.Fatalf("second level is %q with %d hazards, want \"Open\" with 1", l.Title, len(l.Hazards))
+	}
+	patrol = []grid.Point{{X: 0, Y: 4}, {X: 1, Y: 4}, {X: 1, Y: 3}, {X: 0, Y: 3}}
+	if h := l.Hazards[0]; !reflect.DeepEqual(h.Path, patrol) || !h.Loop || h.Every != hazardEvery {
+		t.Errorf("patrol goes along %v, loop %v, every %d, want %v round and round every %d", h.Path, h.Loop, h.Every, patrol, hazardEvery)
+	}
+
+	levels, err = ParseLevels(strings.NewReader(".....\n.....\n..>..\n.....\n.....\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if levels[0].Title != "Level 1" {
+		t.Errorf("untitled level is %q, want \"Level 1\"", levels[0].Title)
+	}
+}
+
+func TestParseLevelsErrors(t *testing.T) {
+	const board = ".....\n.....\n..>..\n.....\n.....\n"
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"no levels", "; only a comment\n", "no levels found"},
+		{"bad glyph", "..x..\n", `line 1: "..x.." is neither a board row, a comment nor a title`},
+		{"bad glyph after a board", board + "..@..\n", `line 6: "..@.." is neither a board row, a comment nor a title`},
+		{"ragged row", ".....\n....\n..>..\n.....\n.....\n", "level 1 (lines 1-5): row 2 is 4 cells wide, the first row is 5"},
+		{"too small", "....\n..>.\n....\n....\n", "level is 4x4, the size must be between 5x5 and 40x40"},
+		{"no snake", ".....\n.....\n.....\n.....\n.....\n", "no snake"},
+		{"lone portal", ".....\n1....\n..>..\n.....\n.....\n", "portal 1 marks 1 cells, it must mark two"},
+		{"three portals", "1...1\n.....\n..>..\n.....\n....1\n", "portal 1 marks 3 cells, it must mark two"},
+		{"portals side by side", "11...\n.....\n..>..\n.....\n.....\n", "is next to another portal"},
+		{"body on a portal", ".....\n.....\n1.>..\n.....\n....1\n", "snake 1 starting at row 3, column 3 doesn't fit, its body lies on a portal"},
+		{"body off the board", ".....\n.....\n.>...\n.....\n.....\n", "snake 1 starting at row 3, column 2 doesn't fit, its body runs off the board"},
+		{"body in a wall", ".....\n.....\n#.>..\n.....\n.....\n", "snake 1 starting at row 3, column 3 doesn't fit, its body runs into a wall"},
+		{"facing a wall", ".....\n.....\n..>#.\n.....\n.....\n", "snake 1 starting at row 3, column 3 faces a wall"},
+		{"snakes overlap", ".....\n.....\n..>>.\n.....\n.....\n", "snake 2 starting at row 3, column 4 runs into another snake"},
+		{"walled off", ".#...\n#....\n..>..\n.....\n.....\n", "row 1, column 1 can't be reached by the snakes"},
+		{"hazard on the snake", ".....\n.....\n..>-.\n.....\n.....\n", "hazard at row 3, column 4 is on snake 1 or right in front of it"},
+		{"patrol first", "Patrol: 1,1 1,5\n" + board, "line 1: a patrol comes after the board it's on"},
+		{"patrol of one cell", board + "Patrol: 1,1\n", "line 6: a patrol needs at least two cells"},
+		{"patrol of one cell and a speed", board + "Patrol: 1,1 every 3\n", "line 6: a patrol needs at least two cells"},
+		{"patrol speed", board + "Patrol: 1,1 1,5 every often\n", `line 6: "often" is not a number of steps`},
+		{"patrol cell", board + "Patrol: 1,1 one,5\n", `line 6: "one,5" is not a cell, write it as row,column`},
+		{"patrol off the board", board + "Patrol: 1,1 1,200000000\n", "line 6: patrol cell row 1, column 200000000 is off the board"},
+		{"patrol row off the board", board + "Patrol: 0,1 1,1\n", "line 6: patrol cell row 0, column 1 is off the board"},
+		{"patrol diagonal", board + "Patrol: 1,1 2,2\n", "line 6: the patrol can't go straight from row 1, column 1 to row 2, column 2"},
+		{"patrol standing still", board + "Patrol: 1,1 1,1\n", "line 6: the patrol can't go straight from row 1, column 1 to row 1, column 1"},
+		{"patrol through a wall", "..#..\n.....\n..>..\n.....\n.....\nPatrol: 1,1 1,5\n", "line 6: hazard at row 1, column 1 goes through row 1, column 3, which is not an empty cell"},
+		{"patrol over the snake", board + "Patrol: 3,1 3,5\n", "line 6: hazard at row 3, column 1 is on snake 1 or right in front of it"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseLevels(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestNewHexLevelErrors(t *testing.T) {
+	// hex cells have no neighbor to the east or west
+	start := []Start{{Head: grid.Point{X: 5, Y: 5}, Dir: grid.East}}
+	if _, err := NewHexLevel("", 10, 10, nil, start, nil, nil); err == nil ||
+		err.Error() != "snake 1 starting at row 5, column 6 heads where the cells have no neighbor" {
+		t.Errorf("got error %v for a snake heading east", err)
+	}
+	l := mustLevel(NewHexLevel("", 10, 10, nil, nil, nil, nil))
+	if err := l.AddHazard(Hazard{At: grid.Point{X: 2, Y: 2}, Dir: grid.West, Every: hazardEvery}); err == nil ||
+		err.Error() != "hazard at row 8, column 3 heads where the cells have no neighbor" {
+		t.Errorf("got error %v for a hazard heading west", err)
+	}
+	if _, err := NewHexLevel("", 9, 10, nil, nil, nil, nil); err == nil || err.Error() != "hex level is 9 columns wide, it must be even" {
+		t.Errorf("got error %v for an odd width", err)
+	}
+}
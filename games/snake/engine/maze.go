@@ -0,0 +1,100 @@
+// maze.go generates the levels of endless games from a seed
+
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/miluchen/games-in-go/games/grid"
+)
+
+const (
+	corridorLength = 5 // free cells ahead of the snake when it starts on a maze
+	minRoom        = 3 // min width or height of a room
+	doorWidth      = 2 // width of the gap in the wall between two rooms
+	maxDivisions   = 3 // how many times the board is split into rooms, at most
+	maxSegments    = 12
+)
+
+// Maze returns level n of the endless game played with seed. The same seed and level always give
+// the same board. A board is either rooms, walls splitting the board with a door in each, or wall
+// segments scattered over it, and later levels have more walls. Every free cell stays connected to
+// the others, and the snake starts on the left heading east along a free corridor.
+func Maze(seed int64, n int) *Level {
+	// each level gets its own stream of numbers, so a level doesn't depend on the ones before it
+	rng := rand.New(rand.NewSource(int64(uint64(seed) + uint64(n)*0x9e3779b97f4a7c15)))
+	start := Start{Head: grid.Point{X: startLength - 1, Y: Height / 2}, Dir: grid.East}
+	var candidates []grid.Point
+	if rng.Intn(2) == 0 {
+		divide(rng, 0, 0, Width-1, Height-1, min(1+n/2, maxDivisions), &candidates)
+	} else {
+		candidates = segments(rng, min(3+n, maxSegments))
+	}
+
+	l := &Level{Topology: grid.Square, Width: Width, Height: Height, walls: make([]bool, Width*Height)}
+	corridor := make(map[grid.Point]bool)
+	for x := 0; x < startLength+corridorLength; x++ {
+		corridor[grid.Point{X: x, Y: start.Head.Y}] = true
+	}
+	var walls []grid.Point
+	for _, p := range candidates {
+		if corridor[p] || l.walls[l.index(p)] {
+			continue
+		}
+		// walls cutting off any cell are left out, which opens extra doors where needed
+		l.walls[l.index(p)] = true
+		if _, ok := l.unreachable(start.Head); ok {
+			l.walls[l.index(p)] = false
+			continue
+		}
+		walls = append(walls, p)
+	}
+	return mustLevel(NewLevel(fmt.Sprintf("Maze %d", n), Width, Height, walls, []Start{start}, nil, nil))
+}
+
+// divide splits the area from (x0, y0) to (x1, y1) into two rooms with a wall across it, leaving a
+// door in the wall, then splits the rooms again until depth runs out or they are too small
+func divide(rng *rand.Rand, x0, y0, x1, y1, depth int, walls *[]grid.Point) {
+	width, height := x1-x0+1, y1-y0+1
+	canSplitRows, canSplitColumns := height >= 2*minRoom+1, width >= 2*minRoom+1
+	if depth == 0 || !canSplitRows && !canSplitColumns {
+		return
+	}
+	// the longer side is cut in two, so rooms stay about square
+	if canSplitRows && (!canSplitColumns || height > width || height == width && rng.Intn(2) == 0) {
+		y := y0 + minRoom + rng.Intn(height-2*minRoom)
+		door := x0 + rng.Intn(width-doorWidth+1)
+		for x := x0; x <= x1; x++ {
+			if x < door || x >= door+doorWidth {
+				*walls = append(*walls, grid.Point{X: x, Y: y})
+			}
+		}
+		divide(rng, x0, y0, x1, y-1, depth-1, walls)
+		divide(rng, x0, y+1, x1, y1, depth-1, walls)
+		return
+	}
+	x := x0 + minRoom + rng.Intn(width-2*minRoom)
+	door := y0 + rng.Intn(height-doorWidth+1)
+	for y := y0; y <= y1; y++ {
+		if y < door || y >= door+doorWidth {
+			*walls = append(*walls, grid.Point{X: x, Y: y})
+		}
+	}
+	divide(rng, x0, y0, x-1, y1, depth-1, walls)
+	divide(rng, x+1, y0, x1, y1, depth-1, walls)
+}
+
+// segments returns the cells of count straight walls 2 to 4 cells long, placed anywhere
+func segments(rng *rand.Rand, count int) []grid.Point {
+	var walls []grid.Point
+	for i := 0; i < count; i++ {
+		dir := grid.Directions[rng.Intn(len(grid.Directions))]
+		p := grid.Point{X: rng.Intn(Width), Y: rng.Intn(Height)}
+		for length := 2 + rng.Intn(3); length > 0 && p.In(Width, Height); length-- {
+			walls = append(walls, p)
+			p = p.Move(dir)
+		}
+	}
+	return walls
+}
@@ -0,0 +1,257 @@
+// path.go contains path finding on the snake grid, used by the autopilot
+
+package engine
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/miluchen/games-in-go/games/grid"
+)
+
+// board is the grid as seen by path finding. The snake's body leaves cells as it moves:
+// body[i], counting from the tail, is left after i+1 steps, so it can be entered from step i+2 on.
+// The bodies of other snakes are expected to leave their cells the same way.
+type board struct {
+	width  int
+	height int
+	wrap   bool   // whether the opposite sides of the grid are joined
+	free   []int  // step from which a cell can be entered, 0 if it's free already
+	level  *Level // the level, for its portals
+	length int    // length of the snake, a cell it enters stays taken about that many steps
+	// steps at which hazards are on each cell, by cell index, as far as path finding looks ahead
+	hazards map[int][]int
+}
+
+func (g *Game) newBoard(body []grid.Point, others ...[]grid.Point) *board {
+	b := g.emptyBoard()
+	for _, other := range others {
+		for i, p := range other {
+			b.free[b.index(p)] = i + 2
+		}
+	}
+	for i, p := range body {
+		b.free[b.index(p)] = i + 2
+	}
+	b.length = len(body)
+	return b
+}
+
+// newStillBoard returns the board as if the body stood still except for its tail, which moves away
+// just in time when the head follows it. This doesn't count on any other cell being left, so a tail
+// reachable on this board can be followed whatever the snake does meanwhile. Other snakes are
+// taken as standing still.
+func (g *Game) newStillBoard(body []grid.Point, others ...[]grid.Point) *board {
+	b := g.emptyBoard()
+	for _, other := range others {
+		for _, p := range other {
+			b.free[b.index(p)] = math.MaxInt32
+		}
+	}
+	for _, p := range body {
+		b.free[b.index(p)] = math.MaxInt32
+	}
+	b.free[b.index(body[0])] = 2
+	b.length = len(body)
+	return b
+}
+
+// emptyBoard returns the board with nothing but the level's walls, portals and hazards on it
+func (g *Game) emptyBoard() *board {
+	b := &board{width: g.Width, height: g.Height, wrap: g.Wrap, free: make([]int, g.Width*g.Height), level: g.Level}
+	for _, p := range g.Walls {
+		b.free[b.index(p)] = math.MaxInt32
+	}
+	for p := range g.portals {
+		b.free[b.index(p)] = math.MaxInt32
+	}
+	if len(g.Hazards) > 0 {
+		b.hazards = make(map[int][]int)
+	}
+	for _, h := range g.Hazards {
+		for step := 0; step <= hazardHorizon; step++ {
+			b.hazards[b.index(h.At)] = append(b.hazards[b.index(h.At)], step)
+			h = g.moved(h)
+		}
+	}
+	return b
+}
+
+func (b *board) index(p grid.Point) int {
+	return p.Y*b.width + p.X
+}
+
+// enterable reports whether p can be entered on step. A cell a hazard passes by while the snake
+// would be on it can't, nor can the cell a hazard leaves as the head comes since they would cross.
+func (b *board) enterable(p grid.Point, step int) bool {
+	if !p.In(b.width, b.height) || b.free[b.index(p)] > step {
+		return false
+	}
+	for _, t := range b.hazards[b.index(p)] {
+		if t >= step-1 && t <= step+b.length+hazardMargin {
+			return false
+		}
+	}
+	return true
+}
+
+// neighbor returns the cell next to p in direction dir, through a portal if there is one
+func (b *board) neighbor(p grid.Point, dir grid.Direction) grid.Point {
+	return b.level.step(p, dir, b.wrap)
+}
+
+// estimate returns a lower bound of the steps from p to goal. Portals can make any path shorter,
+// so there is none on levels that have some, and A* searches like breadth-first search.
+func (b *board) estimate(p, goal grid.Point) int {
+	if len(b.level.portals) > 0 {
+		return 0
+	}
+	return b.level.distance(p, goal, b.wrap)
+}
+
+// directionTo returns the direction of the neighbor to of from
+func (g *Game) directionTo(from, to grid.Point) grid.Direction {
+	for _, dir := range g.Directions() {
+		if g.Neighbor(from, dir) == to {
+			return dir
+		}
+	}
+	return grid.North
+}
+
+// findPath returns the shortest path from start (excluded) to goal (included) using A*,
+// or nil if goal can't be reached
+func (b *board) findPath(start, goal grid.Point) []grid.Point {
+	steps := make([]int, len(b.free)) // steps to reach each cell, -1 if not reached yet
+	prev := make([]grid.Point, len(b.free))
+	for i := range steps {
+		steps[i] = -1
+	}
+	steps[b.index(start)] = 0
+	open := &pathQueue{{p: start, cost: b.estimate(start, goal)}}
+	for open.Len() > 0 {
+		cur := heap.Pop(open).(pathNode)
+		if cur.p == goal {
+			break
+		}
+		step := steps[b.index(cur.p)] + 1
+		for _, dir := range b.level.Directions() {
+			next := b.neighbor(cur.p, dir)
+			if !b.enterable(next, step) {
+				continue
+			}
+			if i := b.index(next); steps[i] == -1 || step < steps[i] {
+				steps[i] = step
+				prev[i] = cur.p
+				heap.Push(open, pathNode{p: next, cost: step + b.estimate(next, goal)})
+			}
+		}
+	}
+	if !goal.In(b.width, b.height) || steps[b.index(goal)] == -1 {
+		return nil
+	}
+	path := make([]grid.Point, steps[b.index(goal)])
+	for p, i := goal, len(path)-1; i >= 0; p, i = prev[b.index(p)], i-1 {
+		path[i] = p
+	}
+	return path
+}
+
+// reachable returns the number of cells reachable from start and the number of steps to reach target,
+// which is -1 if target can't be reached
+func (b *board) reachable(start, target grid.Point) (int, int) {
+	visited := make([]bool, len(b.free))
+	visited[b.index(start)] = true
+	count, steps := 0, -1
+	queue := []grid.Point{start}
+	for step := 1; len(queue) > 0; step++ {
+		var next []grid.Point
+		for _, p := range queue {
+			for _, dir := range b.level.Directions() {
+				n := b.neighbor(p, dir)
+				// a cell that can't be entered yet may still be reached later on another way
+				if !b.enterable(n, step) || visited[b.index(n)] {
+					continue
+				}
+				visited[b.index(n)] = true
+				count++
+				if n == target && steps == -1 {
+					steps = step
+				}
+				next = append(next, n)
+			}
+		}
+		queue = next
+	}
+	return count, steps
+}
+
+// canReachTail reports whether the snake can follow its tail, which keeps it alive whatever happens
+// as long as the other snakes don't get in the way
+func (g *Game) canReachTail(body []grid.Point, others ...[]grid.Point) bool {
+	if len(body) >= g.cells() {
+		return true
+	}
+	_, steps := g.newStillBoard(body, others...).reachable(body[len(body)-1], body[0])
+	return steps != -1
+}
+
+// follow returns the body after the head went along path, eating the apple at its end if ate is set
+func follow(body []grid.Point, path []grid.Point, ate bool) []grid.Point {
+	length := len(body)
+	if ate {
+		length++
+	}
+	moved := append(append([]grid.Point(nil), body...), path...)
+	return moved[len(moved)-length:]
+}
+
+// safePath returns the shortest path from the head to the apple after which the snake can
+// still reach its tail, or nil if there is none
+func (g *Game) safePath(body []grid.Point, apple grid.Point, others ...[]grid.Point) []grid.Point {
+	path := g.newBoard(body, others...).findPath(body[len(body)-1], apple)
+	if path == nil || !g.canReachTail(follow(body, path, true), others...) {
+		return nil
+	}
+	return path
+}
+
+type pathNode struct {
+	p    grid.Point
+	cost int // steps so far plus the estimated steps to the goal
+}
+
+// pathQueue is a min-heap of path nodes by cost
+type pathQueue []pathNode
+
+func (q pathQueue) Len() int            { return len(q) }
+func (q pathQueue) Less(i, j int) bool  { return q[i].cost < q[j].cost }
+func (q pathQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *pathQueue) Push(x interface{}) { *q = append(*q, x.(pathNode)) }
+func (q *pathQueue) Pop() interface{} {
+	old := *q
+	node := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return node
+}
+
+// Hint returns the shortest path from the head to the nearest apple after which the snake can
+// still reach its tail, or nil if there is none
+func (g *Game) Hint() []grid.Point {
+	return g.safePath(g.Body, g.NearestApple(g.Head()))
+}
+
+// Trapping tells whether the snake dies or traps itself by moving in direction dir: it can't reach
+// its tail afterwards and there is less room left than its length
+func (g *Game) Trapping(dir grid.Direction) bool {
+	n := g.Neighbor(g.Head(), g.turn(g.Dir, dir))
+	if !g.newBoard(g.Body).enterable(n, 1) {
+		return true
+	}
+	moved := follow(g.Body, []grid.Point{n}, g.IsApple(n))
+	if g.canReachTail(moved) {
+		return false
+	}
+	room, _ := g.newStillBoard(moved).reachable(n, moved[0])
+	return room < len(moved)
+}
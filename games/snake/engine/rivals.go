@@ -0,0 +1,120 @@
+// rivals.go contains the personalities of the rival snakes steered by the computer in a battle
+
+package engine
+
+import "github.com/miluchen/games-in-go/games/grid"
+
+// Personality is how the computer plays a rival snake
+type Personality int
+
+const (
+	Greedy     Personality = iota // goes for the nearest apple or food, whatever happens next
+	Cautious                      // only eats what still lets it reach its tail, and keeps away from other heads
+	Aggressive                    // cuts off snakes coming close, and eats like a greedy snake otherwise
+)
+
+// Personalities lists every personality
+var Personalities = []Personality{Greedy, Cautious, Aggressive}
+
+var personalityNames = []string{Greedy: "Greedy", Cautious: "Cautious", Aggressive: "Aggressive"}
+
+func (p Personality) String() string {
+	return personalityNames[p]
+}
+
+// attackRange is how close another head has to be for an aggressive snake to go after it
+const attackRange = 5
+
+// Steer returns the direction the computer moves snake i in on the next step, playing it with
+// personality p. Snakes that died are expected to have turned into food, see Feed.
+func (g *Game) Steer(i int, p Personality) grid.Direction {
+	s := g.Snakes[i]
+	others := g.others(i)
+	var path []grid.Point
+	switch p {
+	case Greedy:
+		path = g.nearestFood(s, g.newBoard(s.Body, others...), nil)
+	case Cautious:
+		b := g.newBoard(s.Body, others...)
+		g.avoidHeads(i, b)
+		path = g.nearestFood(s, b, func(path []grid.Point) bool {
+			return g.canReachTail(follow(s.Body, path, true), others...)
+		})
+	case Aggressive:
+		if path = g.cutOff(i, others); path == nil {
+			path = g.nearestFood(s, g.newBoard(s.Body, others...), nil)
+		}
+	}
+	if path != nil {
+		return g.directionTo(s.Head(), path[0])
+	}
+	return stall(g, s, others)
+}
+
+// others returns the bodies of the snakes other than snake i
+func (g *Game) others(i int) [][]grid.Point {
+	var bodies [][]grid.Point
+	for j, s := range g.Snakes {
+		if j != i && len(s.Body) > 0 {
+			bodies = append(bodies, s.Body)
+		}
+	}
+	return bodies
+}
+
+// nearestFood returns the shortest path on b from the head of s to an apple or a piece of food,
+// only taking the paths accepted by ok if it's set. It returns nil if there is no such path.
+func (g *Game) nearestFood(s *Snake, b *board, ok func(path []grid.Point) bool) []grid.Point {
+	var best []grid.Point
+	targets := append(append([]grid.Point(nil), g.Apples...), g.Food...)
+	for _, target := range targets {
+		path := b.findPath(s.Head(), target)
+		if len(path) == 0 || best != nil && len(path) >= len(best) || ok != nil && !ok(path) {
+			continue
+		}
+		best = path
+	}
+	return best
+}
+
+// avoidHeads keeps snake i from entering the cells next to the other heads on the next step,
+// where it could meet one of them head-on
+func (g *Game) avoidHeads(i int, b *board) {
+	for j, s := range g.Snakes {
+		if j == i || !s.Alive {
+			continue
+		}
+		for _, dir := range g.Directions() {
+			if n := g.Neighbor(s.Head(), dir); n.In(g.Width, g.Height) && b.free[b.index(n)] < 2 {
+				b.free[b.index(n)] = 2
+			}
+		}
+	}
+}
+
+// cutOff returns a path for snake i to the cell right in front of the nearest snake within
+// attackRange, so it runs into snake i's body, or nil if no snake is close enough or going there
+// would trap snake i
+func (g *Game) cutOff(i int, others [][]grid.Point) []grid.Point {
+	s := g.Snakes[i]
+	var prey *Snake
+	for j, other := range g.Snakes {
+		if j == i || !other.Alive {
+			continue
+		}
+		if d := g.Distance(s.Head(), other.Head()); d <= attackRange && (prey == nil || d < g.Distance(s.Head(), prey.Head())) {
+			prey = other
+		}
+	}
+	if prey == nil {
+		return nil
+	}
+	// aim one cell past the prey's next one, meeting it head-on would kill both snakes
+	next := g.Neighbor(prey.Head(), prey.Dir)
+	target := g.Neighbor(next, prey.Dir)
+	path := g.newBoard(s.Body, others...).findPath(s.Head(), target)
+	if len(path) == 0 || path[0] == next || !g.canReachTail(follow(s.Body, path[:1], g.edible(path[0])), others...) {
+		return nil
+	}
+	return path
+}
@@ -0,0 +1,191 @@
+// Package env wraps the snake rules into an environment for reinforcement learning, in the style of Gym.
+// It runs the same engine the game does, without any rendering.
+package env
+
+import (
+	"math/rand"
+
+	"github.com/miluchen/games-in-go/games/grid"
+	"github.com/miluchen/games-in-go/games/snake/engine"
+)
+
+// NumActions is the number of actions, an action is a grid.Direction from 0 to NumActions-1
+const NumActions = 4
+
+// Encoding is how the game is turned into an observation
+type Encoding int
+
+const (
+	// Grid has 3 planes of Height rows by Width columns: the body, the head and the apples.
+	// A cell is 1 if it holds that thing, 0 otherwise.
+	Grid Encoding = iota
+	// Features has 11 values, each 0 or 1: danger straight ahead, to the right and to the left,
+	// the moving direction as north, east, south, west and whether the nearest apple lies north,
+	// east, south or west of the head.
+	Features
+)
+
+// Size returns the length of an observation in encoding e
+func (e Encoding) Size() int {
+	if e == Features {
+		return 11
+	}
+	return 3 * engine.Width * engine.Height
+}
+
+// Rewards shapes the reward given after each step
+type Rewards struct {
+	Apple  float64 // for eating an apple
+	Death  float64 // for dying, starving included
+	Win    float64 // for filling the board
+	Step   float64 // for every step, a negative value hurries the snake
+	Closer float64 // for moving closer to the nearest apple, moving away costs as much
+}
+
+// Config configures an environment
+type Config struct {
+	Encoding Encoding
+	Rewards  Rewards
+	Starve   int  // steps without eating after which the snake dies, 0 means it never starves
+	Wrap     bool // whether the grid has no wall and the snake comes back on the opposite side
+	Apples   int  // apples on the board at once, 1 if 0
+}
+
+// DefaultConfig encodes features, rewards apples and punishes death only, and lets the snake
+// starve after it could have walked every cell of the board twice
+var DefaultConfig = Config{
+	Encoding: Features,
+	Rewards:  Rewards{Apple: 1, Death: -1, Win: 1},
+	Starve:   2 * engine.Width * engine.Height,
+}
+
+// Env is a snake environment, it's not safe for concurrent use but separate Envs are independent
+type Env struct {
+	config Config
+	game   *engine.Game
+	hunger int // steps since the last apple
+	done   bool
+}
+
+// New creates an environment, Reset must be called before the first Step
+func New(config Config) *Env {
+	return &Env{config: config, done: true}
+}
+
+// Reset starts a new episode, the same seed gives the same apples for the same actions
+func (e *Env) Reset(seed int64) []float32 {
+	e.game = engine.New(rand.New(rand.NewSource(seed)), 1)
+	e.game.Wrap = e.config.Wrap
+	e.game.SetAppleCount(e.config.Apples)
+	e.hunger = 0
+	e.done = false
+	return e.observe()
+}
+
+// Step moves the snake in the direction of action, reversing keeps it going straight as in the game.
+// It returns the observation after the step, the reward for it and whether the episode is over.
+func (e *Env) Step(action grid.Direction) ([]float32, float64, bool) {
+	if e.done {
+		return e.observe(), 0, true
+	}
+	r := e.config.Rewards
+	before := e.game.Distance(e.game.Head(), e.game.NearestApple(e.game.Head()))
+	score := e.game.Score
+	e.game.Step(action)
+	reward := r.Step
+	switch {
+	case !e.game.Alive:
+		e.done = true
+		return e.observe(), reward + r.Death, true
+	case e.game.Won:
+		e.done = true
+		return e.observe(), reward + r.Apple + r.Win, true
+	case e.game.Score > score:
+		e.hunger = 0
+		return e.observe(), reward + r.Apple, false
+	}
+	e.hunger++
+	if e.config.Starve > 0 && e.hunger >= e.config.Starve {
+		e.done = true
+		return e.observe(), reward + r.Death, true
+	}
+	if after := e.game.Distance(e.game.Head(), e.game.NearestApple(e.game.Head())); after < before {
+		reward += r.Closer
+	} else {
+		reward -= r.Closer
+	}
+	return e.observe(), reward, false
+}
+
+// Game returns the game being played, it must not be changed
+func (e *Env) Game() *engine.Game {
+	return e.game
+}
+
+// Starved tells whether the episode ended because the snake starved
+func (e *Env) Starved() bool {
+	return e.done && e.game.Alive && !e.game.Won
+}
+
+// observe encodes the game as configured
+func (e *Env) observe() []float32 {
+	obs := make([]float32, e.config.Encoding.Size())
+	if e.config.Encoding == Features {
+		e.features(obs)
+	} else {
+		e.grid(obs)
+	}
+	return obs
+}
+
+func (e *Env) grid(obs []float32) {
+	plane := engine.Width * engine.Height
+	index := func(p grid.Point) int {
+		return p.Y*engine.Width + p.X
+	}
+	for _, p := range e.game.Body {
+		obs[index(p)] = 1
+	}
+	obs[plane+index(e.game.Head())] = 1
+	for _, apple := range e.game.Apples {
+		obs[2*plane+index(apple)] = 1
+	}
+}
+
+func (e *Env) features(obs []float32) {
+	g := e.game
+	head := g.Head()
+	for i, dir := range []grid.Direction{g.Dir, g.Dir.Right(), g.Dir.Left()} {
+		if e.deadly(g.Neighbor(head, dir)) {
+			obs[i] = 1
+		}
+	}
+	obs[3+int(g.Dir)] = 1
+	apple := g.NearestApple(head)
+	if apple.Y > head.Y {
+		obs[7+int(grid.North)] = 1
+	}
+	if apple.X > head.X {
+		obs[7+int(grid.East)] = 1
+	}
+	if apple.Y < head.Y {
+		obs[7+int(grid.South)] = 1
+	}
+	if apple.X < head.X {
+		obs[7+int(grid.West)] = 1
+	}
+}
+
+// deadly tells whether the head would die entering p on the next step
+func (e *Env) deadly(p grid.Point) bool {
+	if !p.In(engine.Width, engine.Height) {
+		return true
+	}
+	// the whole body counts, the head can't enter the cell the tail is leaving
+	for _, b := range e.game.Body {
+		if b == p {
+			return true
+		}
+	}
+	return false
+}
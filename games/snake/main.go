@@ -1,11 +1,15 @@
 package snake
 
 import (
+	"fmt"
 	"log"
+	"os"
 
 	"github.com/faiface/pixel"
 	"github.com/faiface/pixel/pixelgl"
 	"github.com/miluchen/games-in-go/games/snake/db"
+	"github.com/miluchen/games-in-go/games/snake/engine"
+	"github.com/miluchen/games-in-go/games/snake/netplay"
 	"golang.org/x/image/colornames"
 )
 
@@ -18,6 +22,43 @@ const (
 
 var gameState GameState
 var currentScene *Scene
+var mode gameMode                   // mode of the games started by new game, retry and play again
+var wrap bool                       // whether the next games have no wall, snakes leaving the grid come back on the opposite side
+var appleCount = 1                  // apples on the board at once in the next games
+var hexGrid bool                    // whether the next single player games on the empty board have hex cells
+var demoStrategy int                // index in engine.Strategies of the strategy playing the next demo
+var options Options                 // command line options
+var bot *botStrategy                // running external bot
+var client *netplay.Client          // connection to the server of an online game
+var spectators *netplay.Broadcaster // streams the local games to spectators, nil if nobody may watch
+var levels []*engine.Level          // boards of the campaign from the level file, empty boards are played if nil
+
+// Options are the command line options of the snake game
+type Options struct {
+	Bot      string // command of an external bot that plays the snake, empty if there is none
+	Join     string // address of a server to play online on, empty to play locally
+	Name     string // name of the player online
+	Spectate string // address spectators watch the local games on, empty if nobody may watch
+	Server   string // url of the leaderboard server, empty to keep the scores locally
+	Levels   string // level file the campaign is played on, empty boards are played if empty
+}
+
+// loadLevels reads the level file
+func loadLevels() error {
+	if options.Levels == "" {
+		return nil
+	}
+	f, err := os.Open(options.Levels)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	levels, err = engine.ParseLevels(f)
+	if err != nil {
+		return fmt.Errorf("%s: %v", options.Levels, err)
+	}
+	return nil
+}
 
 func initialize(win *pixelgl.Window) {
 	gameState = InGame
@@ -28,11 +69,47 @@ func initialize(win *pixelgl.Window) {
 		log.Printf("open db failed: %v\n", err)
 		return
 	}
+	if options.Server != "" {
+		db.SetServer(options.Server)
+	}
 	// application starts, push main menu to menuStack
 	initMenus(win)
+	if options.Spectate != "" {
+		spectators, err = netplay.Spectate(options.Spectate)
+		if err != nil {
+			log.Printf("stream to spectators failed: %v\n", err)
+		}
+	}
+	// the bot starts playing right away, ESC leads to the main menu
+	if options.Bot != "" {
+		bot, err = newBotStrategy(options.Bot)
+		if err != nil {
+			log.Printf("start bot failed: %v\n", err)
+			return
+		}
+		startBotGame()
+	}
+	// join the server's lobby right away, ESC leaves it
+	if options.Join != "" {
+		client, err = netplay.Dial(options.Join, options.Name)
+		if err != nil {
+			log.Printf("join %s failed: %v\n", options.Join, err)
+			return
+		}
+		startOnline()
+	}
 }
 
-func close() {
+func shutdown() {
+	if bot != nil {
+		bot.close()
+	}
+	if client != nil {
+		client.Close()
+	}
+	if spectators != nil {
+		spectators.Close()
+	}
 	if err := db.Close(); err != nil {
 		log.Printf("close db failed: %v\n", err)
 	}
@@ -41,10 +118,34 @@ func close() {
 func startGame() {
 	clearMenuStack()
 	// initialize snake game
-	currentScene = &Scene{active: true, snakeGame: newSnakeGame()}
+	currentScene = &Scene{active: true, snakeGame: newSnakeGame(mode)}
+}
+
+// startDemo starts a game played by the autopilot, each demo uses the next strategy
+func startDemo() {
+	clearMenuStack()
+	strategy := engine.Strategies[demoStrategy]()
+	demoStrategy = (demoStrategy + 1) % len(engine.Strategies)
+	currentScene = &Scene{active: true, snakeGame: newDemoGame(), autopilot: strategy}
+}
+
+// startBotGame starts a game played by the external bot
+func startBotGame() {
+	clearMenuStack()
+	currentScene = &Scene{active: true, snakeGame: newDemoGame(), autopilot: bot}
+}
+
+// startOnline shows the online game, which starts in the server's lobby
+func startOnline() {
+	clearMenuStack()
+	currentScene = &Scene{active: true, online: client}
 }
 
 func run() {
+	if err := loadLevels(); err != nil {
+		log.Printf("load levels failed: %v\n", err)
+		return
+	}
 	// initialize window
 	cfg := pixelgl.WindowConfig{
 		Title:  "snake",
@@ -70,9 +171,11 @@ func run() {
 
 		win.Update()
 	}
-	close()
+	shutdown()
 }
 
-func Run() {
+// Run starts the game
+func Run(opts Options) {
+	options = opts
 	pixelgl.Run(run)
 }
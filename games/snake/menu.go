@@ -19,7 +19,9 @@ type Menu struct {
 	textMatrices []pixel.Matrix
 	inputBoxes   []*InputBox
 
-	isLeaderBoard bool // leader board menu needs to read from DB every time
+	generate func(*pixelgl.Window, *Menu) // reads the menu's text from DB every time it's shown, nil if it has none
+	stale    bool                         // text has to be read again before it's drawn
+	updates  int                          // db.Updates() when the text was read, it's read again when scores come from the server
 }
 
 func newMenu() *Menu {
@@ -93,8 +95,12 @@ func (m *Menu) handleEvent(win *pixelgl.Window) {
 }
 
 func (m *Menu) update(win *pixelgl.Window) {
-	if m.isLeaderBoard {
-		generateLeaderBoard(win, m)
+	// the text is only read when the menu is shown, and again when scores came from the leaderboard
+	// server in the background
+	if m.generate != nil && (m.stale || m.updates != db.Updates()) {
+		m.updates = db.Updates()
+		m.generate(win, m)
+		m.stale = false
 	}
 	m.handleEvent(win)
 	m.draw(win)
@@ -107,23 +113,40 @@ func (m *Menu) reset() {
 /* ========== menu handle functions ========== */
 
 var mainMenu *Menu
+var newGameMenu *Menu
+var timeAttackMenu *Menu
 var leaderboardMenu *Menu
 var optionsMenu *Menu
 var pauseMenu *Menu
 var gameOverMenu *Menu
 var winMenu *Menu
 var inputNameMenu *Menu
+var versusMenu *Menu
+var battleMenu *Menu
+var restartButton *RectButton // pause menu button starting the game again, daily challenges can't be
+var wallsButton *RectButton   // options menu button switching the wall on and off
+var applesButton *RectButton  // options menu button choosing the number of apples
+var gridButton *RectButton    // options menu button switching between square and hex cells
+var rankingButton *RectButton // leaderboard menu button choosing the category shown
+var rankingIndex int          // index in rankings of the category the leaderboard shows
 
 var menuStack []*Menu
 
 func initMenus(win *pixelgl.Window) {
 	mainMenu = createMainMenu()
+	newGameMenu = createNewGameMenu()
+	timeAttackMenu = createTimeAttackMenu()
+	summaryMenu = createSummaryMenu()
 	leaderboardMenu = createLeaderBoardMenu(win)
 	optionsMenu = createOptionsMenu()
 	pauseMenu = createPauseMenu()
 	gameOverMenu = createGameOverMenu()
 	winMenu = createWinMenu(win)
-	inputNameMenu = createInputNameMenu(win)
+	inputNameMenu = createInputNameMenu()
+	versusMenu = createVersusMenu()
+	battleMenu = createBattleMenu()
+	dailyMenu = createDailyMenu()
+	dailyOverMenu = createDailyOverMenu()
 
 	menuStack = append(menuStack, mainMenu)
 }
@@ -134,20 +157,50 @@ func createMainMenu() *Menu {
 	rect := pixel.Rect{Min: pixel.V(200, 350), Max: pixel.V(300, 380)}
 	menu.addButton(newRectButton(rect, newGameButtonName, false, newGameHandler))
 	rect = pixel.Rect{Min: pixel.V(200, 310), Max: pixel.V(300, 340)}
-	menu.addButton(newRectButton(rect, leaderBoardButtonName, false, leaderboardHandler))
+	menu.addButton(newRectButton(rect, versusButtonName, false, versusHandler))
 	rect = pixel.Rect{Min: pixel.V(200, 270), Max: pixel.V(300, 300)}
-	menu.addButton(newRectButton(rect, optionsButtonName, false, optionsHandler))
+	menu.addButton(newRectButton(rect, battleButtonName, false, battleHandler))
 	rect = pixel.Rect{Min: pixel.V(200, 230), Max: pixel.V(300, 260)}
+	menu.addButton(newRectButton(rect, leaderBoardButtonName, false, leaderboardHandler))
+	rect = pixel.Rect{Min: pixel.V(200, 190), Max: pixel.V(300, 220)}
+	menu.addButton(newRectButton(rect, demoButtonName, false, demoHandler))
+	rect = pixel.Rect{Min: pixel.V(200, 150), Max: pixel.V(300, 180)}
+	menu.addButton(newRectButton(rect, optionsButtonName, false, optionsHandler))
+	// the name is wider than the other buttons
+	rect = pixel.Rect{Min: pixel.V(190, 110), Max: pixel.V(310, 140)}
+	menu.addButton(newRectButton(rect, dailyButtonName, false, dailyHandler))
+	rect = pixel.Rect{Min: pixel.V(200, 70), Max: pixel.V(300, 100)}
 	menu.addButton(newRectButton(rect, exitButtonName, false, exitHandler))
 	return menu
 }
 
+// createNewGameMenu creates the menu choosing the mode of a single player game
+func createNewGameMenu() *Menu {
+	menu := newMenu()
+	rect := pixel.Rect{Min: pixel.V(200, 310), Max: pixel.V(300, 340)}
+	menu.addButton(newRectButton(rect, classicButtonName, false, classicHandler))
+	rect = pixel.Rect{Min: pixel.V(200, 270), Max: pixel.V(300, 300)}
+	menu.addButton(newRectButton(rect, endlessButtonName, false, endlessHandler))
+	rect = pixel.Rect{Min: pixel.V(200, 230), Max: pixel.V(300, 260)}
+	menu.addButton(newRectButton(rect, timeAttackButtonName, false, timeAttackHandler))
+	rect = pixel.Rect{Min: pixel.V(200, 190), Max: pixel.V(300, 220)}
+	menu.addButton(newRectButton(rect, survivalButtonName, false, survivalHandler))
+	rect = pixel.Rect{Min: pixel.V(200, 150), Max: pixel.V(300, 180)}
+	menu.addButton(newRectButton(rect, practiceButtonName, false, practiceHandler))
+	rect = pixel.Rect{Min: pixel.V(200, 110), Max: pixel.V(300, 140)}
+	menu.addButton(newRectButton(rect, backButtonName, false, backHandler))
+	return menu
+}
+
 func createLeaderBoardMenu(win *pixelgl.Window) *Menu {
 	menu := newMenu()
-	menu.isLeaderBoard = true
+	menu.generate = generateLeaderBoard
 	generateLeaderBoard(win, menu)
-	// add buttons for leaderboard menu
+	// add buttons for leaderboard menu, the first one cycles through the categories
 	rect := pixel.Rect{Min: pixel.V(200, 190), Max: pixel.V(300, 220)}
+	rankingButton = newRectButton(rect, rankings[rankingIndex].title, false, rankingHandler)
+	menu.addButton(rankingButton)
+	rect = pixel.Rect{Min: pixel.V(200, 150), Max: pixel.V(300, 180)}
 	menu.addButton(newRectButton(rect, backButtonName, false, backHandler))
 	return menu
 }
@@ -157,21 +210,29 @@ func generateLeaderBoard(win *pixelgl.Window, menu *Menu) {
 	atlas := text.NewAtlas(basicfont.Face7x13, text.ASCII)
 	txt := text.New(pixel.V(100, 700), atlas)
 	txt.Color = colornames.Green
-	fmt.Fprintln(txt, "Leaderboard")
+	r := rankings[rankingIndex]
+	fmt.Fprintf(txt, "Leaderboard - %s", r.title)
+	if r.hex && hexGrid {
+		fmt.Fprint(txt, " - Hex")
+	}
+	if wrap {
+		fmt.Fprint(txt, " - No Walls")
+	}
+	fmt.Fprintln(txt)
 	matrix := pixel.IM.Moved(win.Bounds().Center().Sub(txt.Bounds().Center()).Add(pixel.V(0, win.Bounds().H()/2-txt.Bounds().H()/2)))
 
 	menu.texts = nil
 	menu.textMatrices = nil
 	menu.addText(txt, matrix)
 
-	names, err := db.Read()
+	entries, err := db.Read(r.boardName(wrap, hexGrid))
 	if err != nil {
 		txt.Color = colornames.Red
 		fmt.Fprintf(txt, "err: %s\n", err.Error())
 	} else {
 		txt.Color = colornames.Greenyellow
-		for i, name := range names {
-			fmt.Fprintf(txt, "%d\t%s\n", i+1, name)
+		for i, entry := range entries {
+			fmt.Fprintf(txt, "%d\t%-16s%d\n", i+1, entry.Name, entry.Score)
 		}
 	}
 }
@@ -179,7 +240,16 @@ func generateLeaderBoard(win *pixelgl.Window, menu *Menu) {
 func createOptionsMenu() *Menu {
 	menu := newMenu()
 	// add buttons for options menu
-	rect := pixel.Rect{Min: pixel.V(200, 230), Max: pixel.V(300, 260)}
+	rect := pixel.Rect{Min: pixel.V(200, 270), Max: pixel.V(300, 300)}
+	wallsButton = newRectButton(rect, wallsLabel(), false, wallsHandler)
+	menu.addButton(wallsButton)
+	rect = pixel.Rect{Min: pixel.V(200, 230), Max: pixel.V(300, 260)}
+	applesButton = newRectButton(rect, applesLabel(), false, applesHandler)
+	menu.addButton(applesButton)
+	rect = pixel.Rect{Min: pixel.V(200, 190), Max: pixel.V(300, 220)}
+	gridButton = newRectButton(rect, gridLabel(), false, gridHandler)
+	menu.addButton(gridButton)
+	rect = pixel.Rect{Min: pixel.V(200, 150), Max: pixel.V(300, 180)}
 	menu.addButton(newRectButton(rect, backButtonName, false, backHandler))
 	return menu
 }
@@ -192,7 +262,8 @@ func createPauseMenu() *Menu {
 	rect = pixel.Rect{Min: pixel.V(200, 310), Max: pixel.V(300, 340)}
 	menu.addButton(newRectButton(rect, resumeButtonName, false, resumeHandler))
 	rect = pixel.Rect{Min: pixel.V(200, 270), Max: pixel.V(300, 300)}
-	menu.addButton(newRectButton(rect, restartButtonName, false, restartHandler))
+	restartButton = newRectButton(rect, restartButtonName, false, restartHandler)
+	menu.addButton(restartButton)
 	rect = pixel.Rect{Min: pixel.V(200, 230), Max: pixel.V(300, 260)}
 	menu.addButton(newRectButton(rect, optionsButtonName, false, optionsHandler))
 	rect = pixel.Rect{Min: pixel.V(200, 190), Max: pixel.V(300, 220)}
@@ -229,15 +300,45 @@ func createWinMenu(win *pixelgl.Window) *Menu {
 	return menu
 }
 
-func createInputNameMenu(win *pixelgl.Window) *Menu {
+func createVersusMenu() *Menu {
 	menu := newMenu()
-	// add win text
+	// the result text is set when a versus game ends
+	rect := pixel.Rect{Min: pixel.V(200, 310), Max: pixel.V(300, 340)}
+	menu.addButton(newRectButton(rect, playAgainButtonName, false, playAgainHandler))
+	rect = pixel.Rect{Min: pixel.V(200, 270), Max: pixel.V(300, 300)}
+	menu.addButton(newRectButton(rect, mainMenuButtonName, false, mainMenuHandler))
+	return menu
+}
+
+// showVersusResult shows who won the versus game or the battle and the scores
+func showVersusResult(win *pixelgl.Window, snakeGame *SnakeGame) {
 	atlas := text.NewAtlas(basicfont.Face7x13, text.ASCII)
 	txt := text.New(pixel.V(100, 700), atlas)
 	txt.Color = colornames.Red
-	fmt.Fprintf(txt, "You Win! Your Name:\n")
+	switch winner := snakeGame.Winner(); {
+	case winner == -1:
+		fmt.Fprintln(txt, "Draw!")
+	case snakeGame.mode == battleMode && winner == 0:
+		fmt.Fprintln(txt, "You Win!")
+	case snakeGame.mode == battleMode:
+		fmt.Fprintf(txt, "%s Wins!\n", snakeGame.snakeName(winner))
+	default:
+		fmt.Fprintf(txt, "Player %d Wins!\n", winner+1)
+	}
+	for i, snake := range snakeGame.Snakes {
+		fmt.Fprintf(txt, "%s: %d\n", snakeGame.snakeName(i), snake.Score)
+	}
 	matrix := pixel.IM.Moved(win.Bounds().Center().Sub(txt.Bounds().Center()).Add(pixel.V(0, win.Bounds().H()/2-txt.Bounds().H()/2)))
-	menu.addText(txt, matrix)
+
+	versusMenu.texts = nil
+	versusMenu.textMatrices = nil
+	versusMenu.addText(txt, matrix)
+	menuStack = append(menuStack, versusMenu)
+}
+
+func createInputNameMenu() *Menu {
+	menu := newMenu()
+	// the headline is set when the name is asked for
 	// add input box
 	rect := pixel.Rect{Min: pixel.V(200, 350), Max: pixel.V(300, 380)}
 	menu.setInputBox(newInputBox(rect))
@@ -253,6 +354,20 @@ func createInputNameMenu(win *pixelgl.Window) *Menu {
 	return menu
 }
 
+// askName asks for the name the score is saved with, headline tells how the game ended
+func askName(win *pixelgl.Window, headline string) {
+	atlas := text.NewAtlas(basicfont.Face7x13, text.ASCII)
+	txt := text.New(pixel.V(100, 700), atlas)
+	txt.Color = colornames.Red
+	fmt.Fprintf(txt, "%s Your Name:\n", headline)
+	matrix := pixel.IM.Moved(win.Bounds().Center().Sub(txt.Bounds().Center()).Add(pixel.V(0, win.Bounds().H()/2-txt.Bounds().H()/2)))
+
+	inputNameMenu.texts = nil
+	inputNameMenu.textMatrices = nil
+	inputNameMenu.addText(txt, matrix)
+	menuStack = append(menuStack, inputNameMenu)
+}
+
 func clearMenuStack() {
 	for _, menu := range menuStack {
 		menu.reset()
@@ -0,0 +1,176 @@
+package netplay
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/miluchen/games-in-go/games/grid"
+	"github.com/miluchen/games-in-go/games/snake/engine"
+)
+
+// Client is a player connected to a server, it keeps its own copy of the game up to date
+type Client struct {
+	Player int // number of the player, from 1
+
+	conn     net.Conn
+	messages chan Message
+
+	writeMu sync.Mutex // guards encoder
+	encoder *json.Encoder
+
+	mu      sync.Mutex
+	lobby   []LobbyPlayer
+	game    *engine.Game // last game seen, nil until the first game starts
+	players []int        // player owning each snake of game
+	playing bool         // whether game is in progress
+	result  Message      // over message of the last game
+	err     error        // why the connection ended, nil while it's open
+}
+
+// Dial connects to the server at addr and joins its lobby as name
+func Dial(addr, name string) (*Client, error) {
+	conn, err := net.DialTimeout("tcp", addr, joinTimeout)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{conn: conn, encoder: json.NewEncoder(conn), messages: make(chan Message, outboxSize)}
+	if err := c.encoder.Encode(Message{Type: JoinMessage, Name: name}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	reader := bufio.NewScanner(conn)
+	conn.SetReadDeadline(time.Now().Add(joinTimeout))
+	var welcome Message
+	if !reader.Scan() {
+		conn.Close()
+		return nil, fmt.Errorf("no answer from %s", addr)
+	}
+	if err := json.Unmarshal(reader.Bytes(), &welcome); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if welcome.Type != WelcomeMessage {
+		conn.Close()
+		return nil, fmt.Errorf("refused by %s: %s", addr, welcome.Error)
+	}
+	conn.SetReadDeadline(time.Time{})
+	c.Player = welcome.Player
+	go c.read(reader)
+	return c, nil
+}
+
+// Ready tells the server whether the player is ready to start
+func (c *Client) Ready(ready bool) error {
+	return c.send(Message{Type: ReadyMessage, Ready: ready})
+}
+
+// Turn asks the server to turn the player's snake to dir
+func (c *Client) Turn(dir grid.Direction) error {
+	return c.send(Message{Type: InputMessage, Direction: directionNames[dir]})
+}
+
+// Close disconnects from the server
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Messages returns every message received from the server after the welcome. It's closed when the
+// connection ends. Messages are dropped while the channel is full, the client's state stays right.
+func (c *Client) Messages() <-chan Message {
+	return c.messages
+}
+
+// Lobby returns the players in the lobby
+func (c *Client) Lobby() []LobbyPlayer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]LobbyPlayer(nil), c.lobby...)
+}
+
+// Game returns a copy of the last game seen, the player owning each snake and whether the game
+// is in progress. The game is nil until the first game with snakes starts.
+func (c *Client) Game() (*engine.Game, []int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.game == nil || len(c.game.Snakes) == 0 {
+		return nil, nil, false
+	}
+	g := &engine.Game{Level: c.game.Level, Apples: append([]grid.Point(nil), c.game.Apples...),
+		Food: append([]grid.Point(nil), c.game.Food...), Items: append([]engine.Item(nil), c.game.Items...),
+		Hazards: append([]engine.Hazard(nil), c.game.Hazards...), Wrap: c.game.Wrap, Won: c.game.Won}
+	for _, s := range c.game.Snakes {
+		copied := *s
+		copied.Body = append([]grid.Point(nil), s.Body...)
+		g.Snakes = append(g.Snakes, &copied)
+	}
+	g.Snake = g.Snakes[0]
+	return g, append([]int(nil), c.players...), c.playing
+}
+
+// Result returns the over message of the last game, its type is empty if no game has ended yet
+func (c *Client) Result() Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.result
+}
+
+// Err returns why the connection ended, or nil while it's open
+func (c *Client) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *Client) send(msg Message) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.encoder.Encode(msg)
+}
+
+// read applies the server's messages until the connection ends
+func (c *Client) read(reader *bufio.Scanner) {
+	for reader.Scan() {
+		var msg Message
+		if err := json.Unmarshal(reader.Bytes(), &msg); err != nil || !msg.complete() {
+			// a server sending broken messages can't crash the client
+			continue
+		}
+		c.apply(msg)
+		select {
+		case c.messages <- msg:
+		default:
+		}
+	}
+	c.mu.Lock()
+	c.err = reader.Err()
+	if c.err == nil {
+		c.err = fmt.Errorf("disconnected by the server")
+	}
+	c.mu.Unlock()
+	close(c.messages)
+}
+
+// apply updates the client's state with msg
+func (c *Client) apply(msg Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch msg.Type {
+	case LobbyMessage:
+		c.lobby = msg.Lobby
+	case SnapshotMessage:
+		c.game = msg.Snapshot.game()
+		c.players = msg.Snapshot.Players
+		c.playing = true
+	case DeltaMessage:
+		if c.game != nil {
+			msg.Delta.apply(c.game)
+		}
+	case OverMessage:
+		c.playing = false
+		c.result = msg
+	}
+}
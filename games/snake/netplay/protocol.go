@@ -0,0 +1,355 @@
+// Package netplay lets several players play snake over TCP. The server hosts the game and owns the
+// rules, clients only send their turns and draw what the server tells them.
+//
+// Server and clients exchange one JSON Message per line. A client joins with "join", then the
+// server answers with "welcome" and keeps everyone up to date with "lobby". When at least
+// MinPlayers players are in the lobby and all of them are "ready", the server starts the game
+// with a "snapshot" of the whole state and sends a "delta" on every tick from then on, with a new
+// snapshot every SnapshotEvery ticks. Clients turn their snake with "input". The game ends with
+// "over", after which everyone is back in the lobby and has to get ready again.
+package netplay
+
+import (
+	"strings"
+	"time"
+
+	"github.com/miluchen/games-in-go/games/grid"
+	"github.com/miluchen/games-in-go/games/snake/engine"
+)
+
+const (
+	MinPlayers    = 2  // players needed to start a game
+	MaxPlayers    = 4  // players in a game, the lobby doesn't take more
+	SnapshotEvery = 50 // ticks between two snapshots, so a client never drifts for long
+
+	Tick = 200 * time.Millisecond // time between two steps, as fast as a local versus game
+)
+
+// message types, see the package comment
+const (
+	JoinMessage     = "join"
+	ReadyMessage    = "ready"
+	InputMessage    = "input"
+	WelcomeMessage  = "welcome"
+	LobbyMessage    = "lobby"
+	SnapshotMessage = "snapshot"
+	DeltaMessage    = "delta"
+	OverMessage     = "over"
+	ErrorMessage    = "error"
+)
+
+// Message is every message of the protocol, Type tells which fields are set
+type Message struct {
+	Type      string        `json:"type"`
+	Name      string        `json:"name,omitempty"`      // join: name of the player
+	Ready     bool          `json:"ready,omitempty"`     // ready: whether the player is ready
+	Direction string        `json:"direction,omitempty"` // input: up, right, down or left
+	Player    int           `json:"player,omitempty"`    // welcome: number of the player, from 1; over: the winner, 0 for a draw
+	Lobby     []LobbyPlayer `json:"lobby,omitempty"`     // lobby: players connected, in the order they joined
+	Tick      int           `json:"tick,omitempty"`      // snapshot, delta: ticks since the game started
+	Snapshot  *Snapshot     `json:"snapshot,omitempty"`  // snapshot: the whole game
+	Delta     *Delta        `json:"delta,omitempty"`     // delta: what changed during the tick
+	Scores    []int         `json:"scores,omitempty"`    // over: final score of each snake
+	Error     string        `json:"error,omitempty"`     // error: why the server refuses the client
+}
+
+// complete tells whether msg carries the payload its type needs
+func (msg Message) complete() bool {
+	switch msg.Type {
+	case SnapshotMessage:
+		return msg.Snapshot != nil
+	case DeltaMessage:
+		return msg.Delta != nil
+	}
+	return true
+}
+
+// LobbyPlayer is a player waiting in the lobby
+type LobbyPlayer struct {
+	Player int    `json:"player"`
+	Name   string `json:"name"`
+	Ready  bool   `json:"ready"`
+}
+
+// Snapshot is the whole game, snake i belongs to Players[i]
+type Snapshot struct {
+	Width   int         `json:"width"`
+	Height  int         `json:"height"`
+	Players []int       `json:"players"`
+	Snakes  []SnakeView `json:"snakes"`
+	Apples  [][2]int    `json:"apples"`
+	Food    [][2]int    `json:"food,omitempty"`    // food left by dead snakes
+	Wrap    bool        `json:"wrap,omitempty"`    // whether the grid has no wall, snakes leaving it come back on the opposite side
+	Walls   [][2]int    `json:"walls,omitempty"`   // walls inside the grid
+	Items   []ItemView  `json:"items,omitempty"`   // special items on the board
+	Portals [][2][2]int `json:"portals,omitempty"` // pairs of portals
+	Hazards [][2]int    `json:"hazards,omitempty"` // where the hazards are
+	Hex     bool        `json:"hex,omitempty"`     // whether the cells are hexagons
+}
+
+// ItemView is a special item on the board
+type ItemView struct {
+	Kind string `json:"kind"` // golden, slow, ghost, shrink or poison
+	At   [2]int `json:"at"`
+	Left int    `json:"left"` // steps before it disappears
+}
+
+// SnakeView is a snake in a snapshot
+type SnakeView struct {
+	Body      [][2]int `json:"body"` // head first, empty once the snake turned into food
+	Direction string   `json:"direction"`
+	Alive     bool     `json:"alive"`
+	Score     int      `json:"score"`
+}
+
+// Delta is what changed during a tick
+type Delta struct {
+	Snakes  []SnakeDelta `json:"snakes"`
+	Apples  [][2]int     `json:"apples"`
+	Food    [][2]int     `json:"food,omitempty"`    // all the food on the board
+	Items   []ItemView   `json:"items,omitempty"`   // all the special items on the board
+	Hazards [][2]int     `json:"hazards,omitempty"` // where the hazards are
+}
+
+// SnakeDelta is how a snake changed: a snake that moved has a new head, and its tail
+// moved with it unless it grew
+type SnakeDelta struct {
+	Moved bool   `json:"moved"`
+	Head  [2]int `json:"head"`
+	Grew  bool   `json:"grew"`
+	Alive bool   `json:"alive"`
+	Score int    `json:"score"`
+}
+
+// names of the directions in messages, y grows towards up
+var directionNames = map[grid.Direction]string{
+	grid.North: "up",
+	grid.East:  "right",
+	grid.South: "down",
+	grid.West:  "left",
+	// hex grids only
+	grid.NorthEast: "up-right",
+	grid.SouthEast: "down-right",
+	grid.SouthWest: "down-left",
+	grid.NorthWest: "up-left",
+}
+
+// parseDirection returns the direction named name
+func parseDirection(name string) (grid.Direction, bool) {
+	for d, n := range directionNames {
+		if n == name {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+func point(p grid.Point) [2]int {
+	return [2]int{p.X, p.Y}
+}
+
+func unpoint(p [2]int) grid.Point {
+	return grid.Point{X: p[0], Y: p[1]}
+}
+
+func points(ps []grid.Point) [][2]int {
+	var out [][2]int
+	for _, p := range ps {
+		out = append(out, point(p))
+	}
+	return out
+}
+
+func unpoints(ps [][2]int) []grid.Point {
+	var out []grid.Point
+	for _, p := range ps {
+		out = append(out, unpoint(p))
+	}
+	return out
+}
+
+func portalViews(pairs [][2]grid.Point) [][2][2]int {
+	var out [][2][2]int
+	for _, pair := range pairs {
+		out = append(out, [2][2]int{point(pair[0]), point(pair[1])})
+	}
+	return out
+}
+
+func unportalViews(pairs [][2][2]int) [][2]grid.Point {
+	var out [][2]grid.Point
+	for _, pair := range pairs {
+		out = append(out, [2]grid.Point{unpoint(pair[0]), unpoint(pair[1])})
+	}
+	return out
+}
+
+func hazardViews(hazards []engine.Hazard) [][2]int {
+	var out [][2]int
+	for _, h := range hazards {
+		out = append(out, point(h.At))
+	}
+	return out
+}
+
+// unhazardViews returns hazards that are only where they are, which is all a client draws
+func unhazardViews(ps [][2]int) []engine.Hazard {
+	var out []engine.Hazard
+	for _, p := range ps {
+		out = append(out, engine.Hazard{At: unpoint(p)})
+	}
+	return out
+}
+
+func itemViews(items []engine.Item) []ItemView {
+	var out []ItemView
+	for _, item := range items {
+		out = append(out, ItemView{Kind: strings.ToLower(item.Kind.String()), At: point(item.At), Left: item.Left})
+	}
+	return out
+}
+
+// unitemViews returns the items viewed, items of unknown kinds are left out
+func unitemViews(views []ItemView) []engine.Item {
+	var out []engine.Item
+	for _, view := range views {
+		for _, k := range engine.Kinds {
+			if strings.ToLower(k.String()) == view.Kind {
+				out = append(out, engine.Item{Kind: k, At: unpoint(view.At), Left: view.Left})
+			}
+		}
+	}
+	return out
+}
+
+// snapshot describes g, players are the numbers of the players owning each snake
+func snapshot(g *engine.Game, players []int) *Snapshot {
+	snap := &Snapshot{Width: g.Width, Height: g.Height, Players: players, Apples: points(g.Apples), Food: points(g.Food),
+		Wrap: g.Wrap, Walls: points(g.Walls), Items: itemViews(g.Items), Portals: portalViews(g.Portals),
+		Hazards: hazardViews(g.Hazards), Hex: g.Topology == grid.Hex}
+	for _, s := range g.Snakes {
+		view := SnakeView{Direction: directionNames[s.Dir], Alive: s.Alive, Score: s.Score}
+		for i := len(s.Body) - 1; i >= 0; i-- {
+			view.Body = append(view.Body, point(s.Body[i]))
+		}
+		snap.Snakes = append(snap.Snakes, view)
+	}
+	return snap
+}
+
+// game rebuilds the game described by the snapshot, it can be drawn but not stepped
+func (snap *Snapshot) game() *engine.Game {
+	newLevel := engine.NewLevel
+	if snap.Hex {
+		newLevel = engine.NewHexLevel
+	}
+	level, err := newLevel("", snap.Width, snap.Height, unpoints(snap.Walls), nil, nil, unportalViews(snap.Portals))
+	if err != nil {
+		// the server sent a board the game can't hold
+		level = engine.DefaultLevel
+	}
+	g := &engine.Game{Level: level, Apples: unpoints(snap.Apples), Food: unpoints(snap.Food), Wrap: snap.Wrap,
+		Items: unitemViews(snap.Items), Hazards: unhazardViews(snap.Hazards)}
+	for _, view := range snap.Snakes {
+		s := &engine.Snake{Alive: view.Alive, Score: view.Score}
+		s.Dir, _ = parseDirection(view.Direction)
+		for i := len(view.Body) - 1; i >= 0; i-- {
+			s.Body = append(s.Body, unpoint(view.Body[i]))
+		}
+		g.Snakes = append(g.Snakes, s)
+	}
+	if len(g.Snakes) > 0 {
+		g.Snake = g.Snakes[0]
+	}
+	return g
+}
+
+// mark is what a delta needs to remember of a snake: where its head was and how long it was
+type mark struct {
+	head   grid.Point
+	length int
+}
+
+// marks returns the mark of each snake of g
+func marks(g *engine.Game) []mark {
+	m := make([]mark, len(g.Snakes))
+	for i, s := range g.Snakes {
+		m[i] = mark{length: len(s.Body)}
+		if len(s.Body) > 0 {
+			m[i].head = s.Head()
+		}
+	}
+	return m
+}
+
+// delta describes how g changed since the snakes were marked with before. It returns nil if the
+// change can't be told with a delta, e.g. when the snakes were put back to their start.
+func delta(g *engine.Game, before []mark) *Delta {
+	if len(before) != len(g.Snakes) {
+		return nil
+	}
+	d := &Delta{Apples: points(g.Apples), Food: points(g.Food), Items: itemViews(g.Items), Hazards: hazardViews(g.Hazards)}
+	for i, s := range g.Snakes {
+		if len(s.Body) == 0 {
+			if before[i].length > 0 {
+				// the snake turned into food
+				return nil
+			}
+			d.Snakes = append(d.Snakes, SnakeDelta{Alive: s.Alive, Score: s.Score})
+			continue
+		}
+		moved := s.Head() != before[i].head
+		grew := len(s.Body) - before[i].length
+		if moved && (!adjacent(g, s.Head(), before[i].head) || grew < 0 || grew > 1) || !moved && grew != 0 {
+			return nil
+		}
+		d.Snakes = append(d.Snakes, SnakeDelta{
+			Moved: moved,
+			Head:  point(s.Head()),
+			Grew:  grew == 1,
+			Alive: s.Alive,
+			Score: s.Score,
+		})
+	}
+	return d
+}
+
+// adjacent tells whether a and b are neighbors on g's grid, across the sides of a grid that wraps
+// and through portals too
+func adjacent(g *engine.Game, a, b grid.Point) bool {
+	for _, dir := range g.Directions() {
+		if g.Neighbor(a, dir) == b {
+			return true
+		}
+	}
+	return false
+}
+
+// apply changes g the way the server's game changed
+func (d *Delta) apply(g *engine.Game) {
+	g.Apples = unpoints(d.Apples)
+	g.Food = unpoints(d.Food)
+	g.Items = unitemViews(d.Items)
+	g.Hazards = unhazardViews(d.Hazards)
+	for i, sd := range d.Snakes {
+		if i >= len(g.Snakes) {
+			break
+		}
+		s := g.Snakes[i]
+		if sd.Moved && len(s.Body) > 0 {
+			head := unpoint(sd.Head)
+			// the snake heads where it just moved
+			for _, dir := range g.Directions() {
+				if g.Neighbor(s.Head(), dir) == head {
+					s.Dir = dir
+				}
+			}
+			s.Body = append(s.Body, head)
+			if !sd.Grew {
+				s.Body = s.Body[1:]
+			}
+		}
+		s.Alive = sd.Alive
+		s.Score = sd.Score
+	}
+}
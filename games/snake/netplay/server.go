@@ -0,0 +1,319 @@
+package netplay
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miluchen/games-in-go/games/grid"
+	"github.com/miluchen/games-in-go/games/snake/engine"
+)
+
+const (
+	joinTimeout = 5 * time.Second // how long a new connection may take to join
+	outboxSize  = 256             // messages queued for a player, a player falling further behind is dropped
+)
+
+// player is a client connected to the server
+type player struct {
+	number int // player number, from 1
+	name   string
+	ready  bool
+	snake  int            // index of the player's snake, -1 if the player isn't in the game
+	action grid.Direction // last direction the player asked for
+	conn   net.Conn
+	outbox chan Message
+}
+
+// Server hosts games for the players connected to it, it's the only one applying the rules
+type Server struct {
+	listener net.Listener
+	tick     time.Duration // time between two steps of the game
+
+	mu      sync.Mutex
+	players []*player // connected players, in the order they joined
+	next    int       // number of the next player to join
+	game    *engine.Game
+	snakes  []*player // owner of each snake of the game, nil once the owner has left
+	ticks   int       // ticks since the game started
+	closed  bool
+}
+
+// Listen starts a server on addr, the game steps every tick. Serve must be called to accept players.
+func Listen(addr string, tick time.Duration) (*Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{listener: listener, tick: tick, next: 1}, nil
+}
+
+// Addr returns the address the server listens on
+func (s *Server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Serve accepts players until the server is closed
+func (s *Server) Serve() error {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return nil
+			}
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+// Close stops the server and disconnects every player
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	for _, p := range s.players {
+		p.conn.Close()
+	}
+	s.mu.Unlock()
+	return s.listener.Close()
+}
+
+// handle runs a connection from the join message until it closes
+func (s *Server) handle(conn net.Conn) {
+	scanner := bufio.NewScanner(conn)
+	conn.SetReadDeadline(time.Now().Add(joinTimeout))
+	var join Message
+	if !scanner.Scan() || json.Unmarshal(scanner.Bytes(), &join) != nil || join.Type != JoinMessage {
+		refuse(conn, "expected a join message")
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+	p, err := s.join(conn, join.Name)
+	if err != nil {
+		refuse(conn, err.Error())
+		return
+	}
+	go p.write()
+	for scanner.Scan() {
+		var msg Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			log.Printf("player %d sent a bad message: %v\n", p.number, err)
+			continue
+		}
+		s.receive(p, msg)
+	}
+	s.leave(p)
+}
+
+// refuse tells a client why it can't join and hangs up
+func refuse(conn net.Conn, reason string) {
+	msg, _ := json.Marshal(Message{Type: ErrorMessage, Error: reason})
+	conn.SetWriteDeadline(time.Now().Add(time.Second))
+	fmt.Fprintf(conn, "%s\n", msg)
+	conn.Close()
+}
+
+// join adds a player to the lobby
+func (s *Server) join(conn net.Conn, name string) (*player, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.players) == MaxPlayers {
+		return nil, fmt.Errorf("the lobby is full")
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = fmt.Sprintf("Player %d", s.next)
+	}
+	p := &player{number: s.next, name: name, snake: -1, conn: conn, outbox: make(chan Message, outboxSize)}
+	s.next++
+	s.players = append(s.players, p)
+	p.send(Message{Type: WelcomeMessage, Player: p.number})
+	s.broadcastLobby()
+	if s.game != nil {
+		// let the new player watch the game in progress
+		p.send(Message{Type: SnapshotMessage, Tick: s.ticks, Snapshot: snapshot(s.game, s.owners())})
+	}
+	return p, nil
+}
+
+// leave removes a player, its snake dies if it's playing
+func (s *Server) leave(p *player) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, q := range s.players {
+		if q == p {
+			s.players = append(s.players[:i], s.players[i+1:]...)
+			break
+		}
+	}
+	close(p.outbox)
+	if p.snake != -1 && s.game != nil {
+		s.game.Snakes[p.snake].Alive = false
+		s.snakes[p.snake] = nil
+	}
+	s.broadcastLobby()
+	s.startIfReady()
+}
+
+// receive handles a message from a player
+func (s *Server) receive(p *player, msg Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch msg.Type {
+	case ReadyMessage:
+		if s.game != nil {
+			return
+		}
+		p.ready = msg.Ready
+		s.broadcastLobby()
+		s.startIfReady()
+	case InputMessage:
+		if dir, ok := parseDirection(msg.Direction); ok {
+			p.action = dir
+		}
+	default:
+		log.Printf("player %d sent an unexpected %q message\n", p.number, msg.Type)
+	}
+}
+
+// startIfReady starts a game when there are enough players in the lobby and all of them are ready
+func (s *Server) startIfReady() {
+	if s.game != nil || len(s.players) < MinPlayers {
+		return
+	}
+	for _, p := range s.players {
+		if !p.ready {
+			return
+		}
+	}
+	s.game = engine.New(rand.New(rand.NewSource(time.Now().UnixNano())), len(s.players))
+	s.snakes = append([]*player(nil), s.players...)
+	s.ticks = 0
+	for i, p := range s.snakes {
+		p.snake = i
+		p.action = s.game.Snakes[i].Dir
+	}
+	s.broadcast(Message{Type: SnapshotMessage, Snapshot: snapshot(s.game, s.owners())})
+	go s.run(s.game)
+}
+
+// run steps game every tick until it's over
+func (s *Server) run(game *engine.Game) {
+	ticker := time.NewTicker(s.tick)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.mu.Lock()
+		if s.closed || s.game != game {
+			s.mu.Unlock()
+			return
+		}
+		s.step()
+		over := s.game == nil
+		s.mu.Unlock()
+		if over {
+			return
+		}
+	}
+}
+
+// step moves the snakes and tells the players what changed
+func (s *Server) step() {
+	actions := make([]grid.Direction, len(s.game.Snakes))
+	for i, snake := range s.game.Snakes {
+		actions[i] = snake.Dir
+		if p := s.snakes[i]; p != nil {
+			actions[i] = p.action
+		}
+	}
+	before := marks(s.game)
+	// the body of a snake whose player left stays in the way
+	s.game.Step(actions...)
+	s.ticks++
+	if d := delta(s.game, before); d != nil && s.ticks%SnapshotEvery != 0 {
+		s.broadcast(Message{Type: DeltaMessage, Tick: s.ticks, Delta: d})
+	} else {
+		s.broadcast(Message{Type: SnapshotMessage, Tick: s.ticks, Snapshot: snapshot(s.game, s.owners())})
+	}
+	if s.game.Survivors() > 1 && !s.game.Won {
+		return
+	}
+	// the game is over, everyone goes back to the lobby
+	msg := Message{Type: OverMessage, Tick: s.ticks}
+	if winner := s.game.Winner(); winner != -1 {
+		msg.Player = s.owners()[winner]
+	}
+	for _, snake := range s.game.Snakes {
+		msg.Scores = append(msg.Scores, snake.Score)
+	}
+	s.broadcast(msg)
+	s.game = nil
+	s.snakes = nil
+	for _, p := range s.players {
+		p.ready = false
+		p.snake = -1
+	}
+	s.broadcastLobby()
+}
+
+// owners returns the number of the player owning each snake, 0 once the player has left
+func (s *Server) owners() []int {
+	numbers := make([]int, len(s.snakes))
+	for i, p := range s.snakes {
+		if p != nil {
+			numbers[i] = p.number
+		}
+	}
+	return numbers
+}
+
+func (s *Server) broadcastLobby() {
+	msg := Message{Type: LobbyMessage, Lobby: []LobbyPlayer{}}
+	for _, p := range s.players {
+		msg.Lobby = append(msg.Lobby, LobbyPlayer{Player: p.number, Name: p.name, Ready: p.ready})
+	}
+	s.broadcast(msg)
+}
+
+func (s *Server) broadcast(msg Message) {
+	for _, p := range s.players {
+		p.send(msg)
+	}
+}
+
+// send queues msg for the player, a player too slow to keep up is disconnected
+func (p *player) send(msg Message) {
+	select {
+	case p.outbox <- msg:
+	default:
+		log.Printf("player %d is falling behind, disconnecting\n", p.number)
+		p.conn.Close()
+	}
+}
+
+// write sends the queued messages until the player leaves
+func (p *player) write() {
+	w := bufio.NewWriter(p.conn)
+	encoder := json.NewEncoder(w)
+	for msg := range p.outbox {
+		if err := encoder.Encode(msg); err != nil {
+			p.conn.Close()
+			continue
+		}
+		// flush once the queue is empty, so a burst of messages goes out together
+		if len(p.outbox) == 0 {
+			if err := w.Flush(); err != nil {
+				p.conn.Close()
+			}
+		}
+	}
+	p.conn.Close()
+}
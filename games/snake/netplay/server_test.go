@@ -0,0 +1,221 @@
+package netplay
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/miluchen/games-in-go/games/grid"
+	"github.com/miluchen/games-in-go/games/snake/engine"
+)
+
+// await reads c's messages until one satisfies ok, and fails the test if none does in time
+func await(t *testing.T, c *Client, ok func(Message) bool) Message {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case msg, open := <-c.Messages():
+			if !open {
+				t.Fatalf("player %d was disconnected: %v", c.Player, c.Err())
+			}
+			if ok(msg) {
+				return msg
+			}
+		case <-timeout:
+			t.Fatalf("player %d timed out waiting for a message", c.Player)
+		}
+	}
+}
+
+// state is what a client knows of the game, without the level each client rebuilds on its own
+type state struct {
+	snakes  []engine.Snake
+	apples  []grid.Point
+	food    []grid.Point
+	players []int
+	playing bool
+}
+
+func stateOf(c *Client) state {
+	g, players, playing := c.Game()
+	if g == nil {
+		return state{}
+	}
+	st := state{apples: g.Apples, food: g.Food, players: players, playing: playing}
+	for _, s := range g.Snakes {
+		st.snakes = append(st.snakes, *s)
+	}
+	return st
+}
+
+// listen starts a server on a free port, it's closed when the test ends
+func listen(t *testing.T) *Server {
+	t.Helper()
+	server, err := Listen("127.0.0.1:0", 5*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- server.Serve() }()
+	t.Cleanup(func() {
+		server.Close()
+		if err := <-served; err != nil {
+			t.Errorf("serve failed: %v", err)
+		}
+	})
+	return server
+}
+
+func TestServer(t *testing.T) {
+	server := listen(t)
+	var clients []*Client
+	for _, name := range []string{"alice", "bob", "carol"} {
+		c, err := Dial(server.Addr().String(), name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+		clients = append(clients, c)
+	}
+	for i, c := range clients {
+		if c.Player != i+1 {
+			t.Fatalf("client %d is player %d", i+1, c.Player)
+		}
+	}
+
+	// the game starts once everyone is ready, the snakes go straight on until they hit a wall
+	for _, c := range clients {
+		if err := c.Ready(true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var results []Message
+	for _, c := range clients {
+		await(t, c, func(msg Message) bool { return msg.Type == SnapshotMessage && msg.Tick == 0 })
+		results = append(results, await(t, c, func(msg Message) bool { return msg.Type == OverMessage }))
+	}
+
+	want := stateOf(clients[0])
+	if want.playing {
+		t.Errorf("player 1 is still playing after the game is over")
+	}
+	if !reflect.DeepEqual(want.players, []int{1, 2, 3}) {
+		t.Errorf("snakes belong to players %v, want [1 2 3]", want.players)
+	}
+	if len(want.snakes) != 3 {
+		t.Fatalf("player 1 sees %d snakes, want 3", len(want.snakes))
+	}
+	for i, s := range want.snakes {
+		if s.Score != results[0].Scores[i] {
+			t.Errorf("snake %d scores %d, the server says %d", i, s.Score, results[0].Scores[i])
+		}
+	}
+	for i, c := range clients[1:] {
+		if got := stateOf(c); !reflect.DeepEqual(got, want) {
+			t.Errorf("player %d sees %+v, player 1 sees %+v", i+2, got, want)
+		}
+		if !reflect.DeepEqual(results[i+1], results[0]) {
+			t.Errorf("player %d got result %+v, player 1 got %+v", i+2, results[i+1], results[0])
+		}
+	}
+
+	// the players left see the lobby without the one who disconnected
+	clients[2].Close()
+	lobby := []LobbyPlayer{{Player: 1, Name: "alice"}, {Player: 2, Name: "bob"}}
+	for _, c := range clients[:2] {
+		await(t, c, func(msg Message) bool { return msg.Type == LobbyMessage && len(msg.Lobby) == 2 })
+		if got := c.Lobby(); !reflect.DeepEqual(got, lobby) {
+			t.Errorf("player %d sees lobby %+v, want %+v", c.Player, got, lobby)
+		}
+	}
+}
+
+func TestServerSkipsMalformedMessages(t *testing.T) {
+	server := listen(t)
+	c, err := Dial(server.Addr().String(), "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	for _, line := range []string{"{not json", `{"type":"input","direction":"sideways"}`, `{"type":"nonsense"}`} {
+		if _, err := fmt.Fprintln(c.conn, line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the player is still in the lobby and the server still listens to it
+	if err := c.Ready(true); err != nil {
+		t.Fatal(err)
+	}
+	await(t, c, func(msg Message) bool {
+		return msg.Type == LobbyMessage && len(msg.Lobby) == 1 && msg.Lobby[0].Ready
+	})
+}
+
+func TestClientSkipsIncompleteMessages(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	// each batch ends with a lobby message, the next one is sent once the test asks for it
+	batches := [][]string{{
+		`{"type":"snapshot","tick":1}`,
+		`{"type":"delta","tick":2}`,
+		`{"type":"snapshot","tick":3,"snapshot":{"width":15,"height":15,"players":[],"snakes":[],"apples":[]}}`,
+	}, {
+		`{"type":"snapshot","tick":4,"snapshot":{"width":15,"height":15,"players":[1],"snakes":[{"body":[],"direction":"up","alive":false,"score":0}],"apples":[]}}`,
+		`{"type":"delta","tick":5,"delta":{"snakes":[{"moved":true,"head":[1,1],"alive":true}],"apples":[]}}`,
+	}}
+	next := make(chan bool)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		bufio.NewReader(conn).ReadString('\n') // the join message
+		fmt.Fprintln(conn, `{"type":"welcome","player":1}`)
+		for _, batch := range batches {
+			<-next
+			for _, line := range batch {
+				fmt.Fprintln(conn, line)
+			}
+			fmt.Fprintln(conn, `{"type":"lobby","lobby":[{"player":1,"name":"alice","ready":false}]}`)
+		}
+		io.Copy(io.Discard, conn)
+	}()
+
+	c, err := Dial(listener.Addr().String(), "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	lobby := func(msg Message) bool {
+		if !msg.complete() {
+			t.Errorf("got a %s message without its payload", msg.Type)
+		}
+		return msg.Type == LobbyMessage
+	}
+
+	next <- true
+	await(t, c, lobby)
+	if g, _, playing := c.Game(); g != nil || playing {
+		t.Errorf("a game without snakes is %v, playing %v, want nil and not playing", g, playing)
+	}
+
+	// a delta moving a snake that turned into food leaves it as it is
+	next <- true
+	await(t, c, lobby)
+	g, _, playing := c.Game()
+	if g == nil || !playing {
+		t.Fatalf("got game %v, playing %v, want the game in progress", g, playing)
+	}
+	if len(g.Snakes[0].Body) != 0 || !g.Snakes[0].Alive {
+		t.Errorf("snake is %v, alive %v, want no body and alive", g.Snakes[0].Body, g.Snakes[0].Alive)
+	}
+}
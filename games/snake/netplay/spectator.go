@@ -0,0 +1,238 @@
+package netplay
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/miluchen/games-in-go/games/snake/engine"
+)
+
+// how long a new connection has to start a WebSocket handshake, a plain TCP spectator sends nothing
+const handshakeWait = 300 * time.Millisecond
+
+// spectator watches the games published by a Broadcaster
+type spectator struct {
+	conn      net.Conn
+	websocket bool
+	outbox    chan Message
+
+	writeMu sync.Mutex // messages and answers to WebSocket control frames are written from two goroutines
+}
+
+// Broadcaster streams a game played in this process to read-only spectators. Spectators connect
+// over TCP and read JSON lines, or over WebSocket and read one message per text frame. The messages
+// are the snapshots, deltas and overs of the online protocol, a spectator joining late gets a
+// snapshot first.
+type Broadcaster struct {
+	listener net.Listener
+
+	mu         sync.Mutex
+	spectators map[*spectator]bool
+	game       *engine.Game  // game being published, only used to tell a new game from the next step
+	level      *engine.Level // level of the game being published, a new level starts with a snapshot
+	before     []mark        // snakes as they were on the last publish
+	last       *Snapshot     // game as it was on the last publish
+	ticks      int           // ticks published since the game started
+	closed     bool
+}
+
+// Spectate starts streaming to spectators connecting on addr, e.g. localhost:7778
+func Spectate(addr string) (*Broadcaster, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	b := &Broadcaster{listener: listener, spectators: make(map[*spectator]bool)}
+	go b.serve()
+	return b, nil
+}
+
+// Addr returns the address spectators connect to
+func (b *Broadcaster) Addr() net.Addr {
+	return b.listener.Addr()
+}
+
+// Close disconnects every spectator
+func (b *Broadcaster) Close() error {
+	b.mu.Lock()
+	b.closed = true
+	for sp := range b.spectators {
+		sp.conn.Close()
+	}
+	b.mu.Unlock()
+	return b.listener.Close()
+}
+
+// Publish sends g to the spectators, it's called after every step of g. A game other than the one
+// published last is a new game, spectators get a snapshot of it.
+func (b *Broadcaster) Publish(g *engine.Game) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var d *Delta
+	switch {
+	case g != b.game:
+		b.game = g
+		b.ticks = 0
+	case g.Level != b.level:
+		b.ticks++
+	default:
+		b.ticks++
+		d = delta(g, b.before)
+	}
+	b.level = g.Level
+	b.before = marks(g)
+	b.last = snapshot(g, players(g))
+	if d != nil && b.ticks%SnapshotEvery != 0 {
+		b.broadcast(Message{Type: DeltaMessage, Tick: b.ticks, Delta: d})
+	} else {
+		b.broadcast(Message{Type: SnapshotMessage, Tick: b.ticks, Snapshot: b.last})
+	}
+}
+
+// Over tells the spectators that g has ended, the winner is only set when several snakes play
+func (b *Broadcaster) Over(g *engine.Game) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	msg := Message{Type: OverMessage, Tick: b.ticks}
+	if winner := g.Winner(); len(g.Snakes) > 1 && winner != -1 {
+		msg.Player = winner + 1
+	}
+	for _, s := range g.Snakes {
+		msg.Scores = append(msg.Scores, s.Score)
+	}
+	b.broadcast(msg)
+}
+
+// players numbers the snakes of a local game from 1
+func players(g *engine.Game) []int {
+	numbers := make([]int, len(g.Snakes))
+	for i := range numbers {
+		numbers[i] = i + 1
+	}
+	return numbers
+}
+
+func (b *Broadcaster) serve() {
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			b.mu.Lock()
+			closed := b.closed
+			b.mu.Unlock()
+			if !closed {
+				log.Printf("accept spectator failed: %v\n", err)
+			}
+			return
+		}
+		go b.watch(conn)
+	}
+}
+
+// watch streams to a spectator until it disconnects
+func (b *Broadcaster) watch(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	// a WebSocket client speaks first, a plain TCP one doesn't
+	conn.SetReadDeadline(time.Now().Add(handshakeWait))
+	start, _ := r.Peek(4)
+	conn.SetReadDeadline(time.Time{})
+	sp := &spectator{conn: conn, websocket: string(start) == "GET ", outbox: make(chan Message, outboxSize)}
+	if sp.websocket {
+		if err := upgrade(conn, r); err != nil {
+			log.Printf("spectator handshake failed: %v\n", err)
+			conn.Close()
+			return
+		}
+	}
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		conn.Close()
+		return
+	}
+	b.spectators[sp] = true
+	if b.last != nil {
+		sp.send(Message{Type: SnapshotMessage, Tick: b.ticks, Snapshot: b.last})
+	}
+	b.mu.Unlock()
+
+	go sp.write()
+	if sp.websocket {
+		sp.control(r)
+	} else {
+		// spectators are read-only, whatever they send is ignored until they hang up
+		io.Copy(io.Discard, r)
+	}
+	b.mu.Lock()
+	delete(b.spectators, sp)
+	close(sp.outbox)
+	b.mu.Unlock()
+}
+
+func (b *Broadcaster) broadcast(msg Message) {
+	for sp := range b.spectators {
+		sp.send(msg)
+	}
+}
+
+// send queues msg for the spectator, a spectator too slow to keep up is disconnected
+func (sp *spectator) send(msg Message) {
+	select {
+	case sp.outbox <- msg:
+	default:
+		sp.conn.Close()
+	}
+}
+
+// write sends the queued messages until the spectator leaves
+func (sp *spectator) write() {
+	for msg := range sp.outbox {
+		payload, err := json.Marshal(msg)
+		if err != nil {
+			log.Printf("encode spectator message failed: %v\n", err)
+			continue
+		}
+		if sp.websocket {
+			payload = websocketFrame(textOpcode, payload)
+		} else {
+			payload = append(payload, '\n')
+		}
+		if err := sp.put(payload); err != nil {
+			sp.conn.Close()
+		}
+	}
+	sp.conn.Close()
+}
+
+// put writes data to the spectator's connection
+func (sp *spectator) put(data []byte) error {
+	sp.writeMu.Lock()
+	defer sp.writeMu.Unlock()
+	_, err := sp.conn.Write(data)
+	return err
+}
+
+// control answers the control frames of a WebSocket spectator until it leaves: a ping gets a pong,
+// and a close is echoed before hanging up. The data it sends is ignored.
+func (sp *spectator) control(r *bufio.Reader) {
+	for {
+		opcode, payload, err := readWebsocketFrame(r)
+		if err != nil {
+			return
+		}
+		switch opcode {
+		case pingOpcode:
+			if sp.put(websocketFrame(pongOpcode, payload)) != nil {
+				return
+			}
+		case closeOpcode:
+			sp.put(websocketFrame(closeOpcode, payload))
+			sp.conn.Close()
+			return
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package netplay
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// maskedFrame builds a frame as a client sends it, with its payload masked
+func maskedFrame(opcode byte, payload []byte) []byte {
+	mask := []byte{0x12, 0x34, 0x56, 0x78}
+	frame := append([]byte{0x80 | opcode, 0x80 | byte(len(payload))}, mask...)
+	for i, c := range payload {
+		frame = append(frame, c^mask[i%4])
+	}
+	return frame
+}
+
+func TestSpectatorControlFrames(t *testing.T) {
+	b, err := Spectate("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	conn, err := net.Dial("tcp", b.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	fmt.Fprint(conn, "GET / HTTP/1.1\r\nHost: localhost\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n")
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusSwitchingProtocols)
+	}
+
+	expect := func(want []byte) {
+		t.Helper()
+		got := make([]byte, len(want))
+		if _, err := io.ReadFull(r, got); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("got frame %x, want %x", got, want)
+		}
+	}
+
+	// text sent by the spectator is skipped, a ping gets a pong with the same payload
+	conn.Write(maskedFrame(textOpcode, []byte("hello")))
+	conn.Write(maskedFrame(pingOpcode, []byte("ping")))
+	expect(websocketFrame(pongOpcode, []byte("ping")))
+
+	// a close is echoed with its status code and the connection is dropped
+	conn.Write(maskedFrame(closeOpcode, []byte{0x03, 0xE8}))
+	expect(websocketFrame(closeOpcode, []byte{0x03, 0xE8}))
+	if n, err := r.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("read %d bytes and %v after the close, want EOF", n, err)
+	}
+	// the spectator is forgotten right after its connection is dropped
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		b.mu.Lock()
+		left := len(b.spectators)
+		b.mu.Unlock()
+		if left == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d spectators after the close, want 0", left)
+		}
+	}
+}
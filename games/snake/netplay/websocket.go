@@ -0,0 +1,106 @@
+package netplay
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// websocketGUID is appended to the client's key to accept a WebSocket connection, see RFC 6455
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// opcodes of the WebSocket frames, a frame with an opcode from closeOpcode up is a control frame
+const (
+	textOpcode  = 0x1
+	closeOpcode = 0x8
+	pingOpcode  = 0x9
+	pongOpcode  = 0xA
+
+	maxControlPayload = 125 // a control frame's payload is never longer
+)
+
+// upgrade answers the WebSocket handshake of the HTTP request waiting in r
+func upgrade(conn net.Conn, r *bufio.Reader) error {
+	req, err := http.ReadRequest(r)
+	if err != nil {
+		return err
+	}
+	key := req.Header.Get("Sec-WebSocket-Key")
+	if !strings.EqualFold(req.Header.Get("Upgrade"), "websocket") || key == "" {
+		fmt.Fprint(conn, "HTTP/1.1 400 Bad Request\r\nConnection: close\r\n\r\n")
+		return fmt.Errorf("not a websocket request")
+	}
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	_, err = fmt.Fprintf(conn, "HTTP/1.1 101 Switching Protocols\r\n"+
+		"Upgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n",
+		base64.StdEncoding.EncodeToString(sum[:]))
+	return err
+}
+
+// websocketFrame wraps payload into an unmasked frame with opcode, as a server sends it
+func websocketFrame(opcode byte, payload []byte) []byte {
+	frame := []byte{0x80 | opcode} // final frame
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, 126, 0, 0)
+		binary.BigEndian.PutUint16(frame[2:], uint16(n))
+	default:
+		frame = append(frame, 127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(frame[2:], uint64(n))
+	}
+	return append(frame, payload...)
+}
+
+// readWebsocketFrame reads a frame sent by a client and returns its opcode. The payload of a
+// control frame is unmasked and returned, the payload of a data frame is skipped.
+func readWebsocketFrame(r *bufio.Reader) (byte, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return 0, nil, err
+	}
+	opcode := head[0] & 0x0F
+	n := uint64(head[1] & 0x7F)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	if opcode >= closeOpcode && n > maxControlPayload || n>>63 != 0 {
+		return 0, nil, fmt.Errorf("websocket frame of %d bytes is too long", n)
+	}
+	var mask [4]byte
+	if head[1]&0x80 != 0 {
+		if _, err := io.ReadFull(r, mask[:]); err != nil {
+			return 0, nil, err
+		}
+	}
+	if opcode < closeOpcode {
+		_, err := io.CopyN(io.Discard, r, int64(n))
+		return opcode, nil, err
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return opcode, payload, nil
+}
@@ -0,0 +1,111 @@
+// online.go shows a game hosted by a netplay server, the server applies the rules and the scene
+// only sends the player's turns and draws what the server tells
+
+package snake
+
+import (
+	"fmt"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"github.com/miluchen/games-in-go/games/snake/netplay"
+	"golang.org/x/image/colornames"
+	"golang.org/x/image/font/basicfont"
+)
+
+// updateOnline sends the player's keys to the server and draws the lobby or the game
+func (s *Scene) updateOnline(win *pixelgl.Window) {
+	if win.JustPressed(pixelgl.KeyEscape) {
+		s.online.Close()
+		client = nil
+		mainMenuHandler()
+		return
+	}
+	game, owners, playing := s.online.Game()
+	if s.online.Err() == nil {
+		if playing {
+			for _, k := range playerKeys[0] {
+				if win.JustPressed(k.key) {
+					s.online.Turn(k.dir)
+					break
+				}
+			}
+		} else if win.JustPressed(pixelgl.KeyR) || win.JustPressed(pixelgl.KeyEnter) {
+			s.online.Ready(!s.ready())
+		}
+	}
+
+	win.Clear(colornames.Aliceblue)
+	if playing {
+		(&SnakeGame{Game: game, mode: versusMode}).draw(win)
+	}
+	atlas := text.NewAtlas(basicfont.Face7x13, text.ASCII)
+	txt := text.New(pixel.V(5, 5), atlas)
+	txt.Color = colornames.Black
+	if !playing {
+		txt = text.New(pixel.V(100, 300), atlas)
+		txt.Color = colornames.Black
+		s.writeLobby(txt, owners)
+	}
+	switch {
+	case s.online.Err() != nil:
+		fmt.Fprintf(txt, "%v - press ESC to leave", s.online.Err())
+	case playing:
+		you := "watching"
+		for i, number := range owners {
+			if number == s.online.Player {
+				you = fmt.Sprintf("you are P%d", i+1)
+			}
+		}
+		fmt.Fprintf(txt, "Online, %s - press ESC to leave", you)
+	default:
+		fmt.Fprint(txt, "press R to toggle ready, ESC to leave")
+	}
+	txt.Draw(win, pixel.IM)
+}
+
+// ready tells whether the player is ready in the lobby
+func (s *Scene) ready() bool {
+	for _, p := range s.online.Lobby() {
+		if p.Player == s.online.Player {
+			return p.Ready
+		}
+	}
+	return false
+}
+
+// writeLobby lists the players waiting and the result of the last game
+func (s *Scene) writeLobby(txt *text.Text, owners []int) {
+	fmt.Fprintln(txt, "Lobby")
+	for _, p := range s.online.Lobby() {
+		state := "waiting"
+		if p.Ready {
+			state = "ready"
+		}
+		you := ""
+		if p.Player == s.online.Player {
+			you = " (you)"
+		}
+		fmt.Fprintf(txt, "%d %s%s: %s\n", p.Player, p.Name, you, state)
+	}
+	fmt.Fprintf(txt, "The game starts when %d or more players are all ready.\n\n", netplay.MinPlayers)
+	result := s.online.Result()
+	if result.Type != netplay.OverMessage {
+		return
+	}
+	switch result.Player {
+	case 0:
+		fmt.Fprintln(txt, "Last game: draw")
+	case s.online.Player:
+		fmt.Fprintln(txt, "Last game: you win!")
+	default:
+		fmt.Fprintf(txt, "Last game: player %d wins\n", result.Player)
+	}
+	for i, score := range result.Scores {
+		if i < len(owners) {
+			fmt.Fprintf(txt, "P%d (player %d): %d\n", i+1, owners[i], score)
+		}
+	}
+	fmt.Fprintln(txt)
+}
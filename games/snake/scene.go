@@ -1,67 +1,151 @@
 package snake
 
 import (
+	"fmt"
+	"time"
+
+	"github.com/faiface/pixel"
 	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"github.com/miluchen/games-in-go/games/grid"
+	"github.com/miluchen/games-in-go/games/snake/engine"
+	"github.com/miluchen/games-in-go/games/snake/netplay"
 	"golang.org/x/image/colornames"
+	"golang.org/x/image/font/basicfont"
 )
 
+// steerKey is a key turning a player's snake to dir
+type steerKey struct {
+	key pixelgl.Button
+	dir grid.Direction
+}
+
+// keys steering each player's snake, player 1 uses the arrow keys and player 2 WASD
+var playerKeys = [][]steerKey{
+	{{pixelgl.KeyLeft, grid.West}, {pixelgl.KeyRight, grid.East}, {pixelgl.KeyDown, grid.South}, {pixelgl.KeyUp, grid.North}},
+	{{pixelgl.KeyA, grid.West}, {pixelgl.KeyD, grid.East}, {pixelgl.KeyS, grid.South}, {pixelgl.KeyW, grid.North}},
+}
+
+// hexKeys steer the snake on hex cells, Q W E over A S D are laid out like the six directions, and
+// so are 7 8 9 over 1 2 3 on the keypad. The arrows up and down still work.
+var hexKeys = []steerKey{
+	{pixelgl.KeyQ, grid.NorthWest}, {pixelgl.KeyW, grid.North}, {pixelgl.KeyE, grid.NorthEast},
+	{pixelgl.KeyA, grid.SouthWest}, {pixelgl.KeyS, grid.South}, {pixelgl.KeyD, grid.SouthEast},
+	{pixelgl.KeyKP7, grid.NorthWest}, {pixelgl.KeyKP8, grid.North}, {pixelgl.KeyKP9, grid.NorthEast},
+	{pixelgl.KeyKP1, grid.SouthWest}, {pixelgl.KeyKP2, grid.South}, {pixelgl.KeyKP3, grid.SouthEast},
+	{pixelgl.KeyUp, grid.North}, {pixelgl.KeyDown, grid.South},
+}
+
 type Scene struct {
 	active    bool
 	snakeGame *SnakeGame
+	autopilot engine.Strategy // strategy steering the snake in the demo, nil if the player plays
+	online    *netplay.Client // connection to the server hosting the game, nil if the game is local
 }
 
 func (s *Scene) draw(win *pixelgl.Window) {
 	win.Clear(colornames.Aliceblue)
 	s.snakeGame.draw(win)
+	if s.autopilot != nil {
+		// tell the viewer who is playing
+		atlas := text.NewAtlas(basicfont.Face7x13, text.ASCII)
+		txt := text.New(pixel.V(5, 5), atlas)
+		txt.Color = colornames.Black
+		fmt.Fprintf(txt, "AI: %s - press ESC to leave", s.autopilot.Name())
+		txt.Draw(win, pixel.IM)
+	}
 }
 
 func (s *Scene) update(win *pixelgl.Window) {
 	if !s.active {
 		return
 	}
+	if s.autopilot != nil {
+		s.updateDemo(win)
+		return
+	}
+	if s.online != nil {
+		s.updateOnline(win)
+		return
+	}
 	// check whether to pause the game
 	if win.JustPressed(pixelgl.KeyEscape) {
 		s.active = false
+		// a daily challenge is played once
+		restartButton.disabled = s.snakeGame.mode == dailyMode
 		menuStack = append(menuStack, pauseMenu)
 		return
 	}
 	// check whether player has won
-	if s.snakeGame.won {
+	if s.snakeGame.Won && len(s.snakeGame.Snakes) == 1 {
 		s.active = false
 		menuStack = append(menuStack, winMenu)
-		menuStack = append(menuStack, inputNameMenu)
+		if s.snakeGame.mode != practiceMode {
+			askName(win, "You Win!")
+		}
 		return
 	}
 
-	if win.JustPressed(pixelgl.KeyLeft) {
-		s.snakeGame.action = West
-		s.snakeGame.state = Moving
-	} else if win.JustPressed(pixelgl.KeyRight) {
-		s.snakeGame.action = East
-		s.snakeGame.state = Moving
-	} else if win.JustPressed(pixelgl.KeyDown) {
-		s.snakeGame.action = South
-		s.snakeGame.state = Moving
-	} else if win.JustPressed(pixelgl.KeyUp) {
-		s.snakeGame.action = North
-		s.snakeGame.state = Moving
+	// route the keys to the player they belong to
+	for i := 0; i < s.snakeGame.players(); i++ {
+		for _, k := range s.snakeGame.keys(i) {
+			if win.JustPressed(k.key) {
+				s.snakeGame.actions[i] = k.dir
+				s.snakeGame.state = Moving
+				break
+			}
+		}
 	}
 
+	// holding a key speeds up the snake, but not in versus where it would be unfair
 	s.snakeGame.repeatedAction = false
-	if win.Repeated(pixelgl.KeyLeft) {
-		s.snakeGame.repeatedAction = true
-	} else if win.Repeated(pixelgl.KeyRight) {
-		s.snakeGame.repeatedAction = true
-	} else if win.Repeated(pixelgl.KeyDown) {
-		s.snakeGame.repeatedAction = true
-	} else if win.Repeated(pixelgl.KeyUp) {
-		s.snakeGame.repeatedAction = true
+	if s.snakeGame.mode != versusMode {
+		for _, k := range s.snakeGame.keys(0) {
+			if win.Repeated(k.key) {
+				s.snakeGame.repeatedAction = true
+				break
+			}
+		}
 	}
 
+	// the rivals are asked once per step, as the autopilot is
+	if s.snakeGame.state == Moving && s.snakeGame.due() {
+		s.snakeGame.steerRivals()
+	}
 	s.snakeGame.move()
+	if len(s.snakeGame.Snakes) > 1 && s.snakeGame.matchOver() {
+		s.active = false
+		showVersusResult(win, s.snakeGame)
+		return
+	}
+	// time attack and survival end on their summary, with the score counting
+	if s.snakeGame.timeUp() || s.snakeGame.timed() && s.snakeGame.dead(win) {
+		s.active = false
+		if spectators != nil && s.snakeGame.Alive {
+			// the snake ran out of time, the spectators heard of it dying otherwise
+			spectators.Over(s.snakeGame.Game)
+		}
+		showSummary(win, s.snakeGame)
+		if s.snakeGame.Alive {
+			askName(win, "Time's Up!")
+		} else {
+			askName(win, "Game Over!")
+		}
+		return
+	}
 	if s.snakeGame.dead(win) {
 		s.active = false
+		// a daily challenge can't be retried, it ends on its result
+		if s.snakeGame.mode == dailyMode {
+			showDailyResult(win, s.snakeGame)
+			askName(win, "Game Over!")
+			return
+		}
 		menuStack = append(menuStack, gameOverMenu)
+		// endless games are only ever lost, the score counts anyway
+		if s.snakeGame.mode == endlessMode {
+			askName(win, "Game Over!")
+		}
 		return
 	}
 	s.draw(win)
@@ -69,4 +153,45 @@ func (s *Scene) update(win *pixelgl.Window) {
 
 func (s *Scene) resume() {
 	s.active = true
+	// the time paused doesn't count
+	if s.snakeGame != nil {
+		s.snakeGame.lastFrame = time.Time{}
+	}
+}
+
+// updateDemo lets the autopilot play, the next strategy takes over when the game ends
+// unless the external bot plays, which plays again
+func (s *Scene) updateDemo(win *pixelgl.Window) {
+	if win.JustPressed(pixelgl.KeyEscape) {
+		mainMenuHandler()
+		return
+	}
+	if s.snakeGame.Won || s.snakeGame.dead(win) {
+		if b, ok := s.autopilot.(*botStrategy); ok {
+			// the bot keeps playing
+			b.gameOver(s.snakeGame.Game)
+			startBotGame()
+			return
+		}
+		startDemo()
+		return
+	}
+	// the autopilot sets the same action as the arrow keys do, it's asked once per step. The
+	// external bot is polled on every frame instead, the game waits for its answer but goes on
+	// being drawn.
+	if s.snakeGame.due() {
+		if b, ok := s.autopilot.(*botStrategy); ok {
+			dir, answered := b.poll(s.snakeGame.Game)
+			if !answered {
+				s.draw(win)
+				return
+			}
+			s.snakeGame.actions[0] = dir
+		} else {
+			s.snakeGame.actions[0] = s.autopilot.Next(s.snakeGame.Game)
+		}
+	}
+	s.snakeGame.state = Moving
+	s.snakeGame.move()
+	s.draw(win)
 }
@@ -2,6 +2,7 @@ package snake
 
 import (
 	"fmt"
+	"image/color"
 	"math"
 	"math/rand"
 	"time"
@@ -10,19 +11,13 @@ import (
 	"github.com/faiface/pixel/imdraw"
 	"github.com/faiface/pixel/pixelgl"
 	"github.com/faiface/pixel/text"
+	"github.com/miluchen/games-in-go/games/grid"
+	"github.com/miluchen/games-in-go/games/snake/db"
+	"github.com/miluchen/games-in-go/games/snake/engine"
 	"golang.org/x/image/colornames"
 	"golang.org/x/image/font/basicfont"
 )
 
-type Direction int
-
-const (
-	North Direction = iota
-	East
-	South
-	West
-)
-
 type snakeState int
 
 const (
@@ -32,50 +27,180 @@ const (
 
 const (
 	Unit     = 20 // size of a square
-	Width    = 15 // width of the grid as in number of units
-	Height   = 15 // height of the grid as in number of units
 	MaxLevel = 1  // max game level
 	AppleCnt = 1  // number of apples to advance to next level
 )
 
-var unitV = pixel.V(Unit, Unit)
+// gameMode is the kind of game started from the main menu
+type gameMode int
 
-type SnakeGame struct {
-	alive bool        // whether the snake is still alive
-	dir   Direction   // snake moving direction
-	body  []pixel.Vec // the coordinates of the whole snake
-	state snakeState  // state indicates whether the snake should is moving
-	won   bool        // whether play has won
+const (
+	classicMode    gameMode = iota // one player going through the levels
+	practiceMode                   // classic with hints drawn, scores aren't kept
+	versusMode                     // two players on one keyboard, until one of them dies
+	battleMode                     // one player against snakes steered by the computer
+	endlessMode                    // one player on maze levels generated from a seed, until the snake dies
+	timeAttackMode                 // one player eating as many apples as possible before the time runs out
+	survivalMode                   // one player at a speed growing all the time, until the snake dies
+	dailyMode                      // endless on the day's levels and apples, once a day
+)
+
+// endlessApples is the number of apples to eat on each level of an endless game
+const endlessApples = 5
+
+// versusLevel sets the speed of versus games, which have no levels
+const versusLevel = 5
 
-	apple          pixel.Vec // position of the apple
-	score          int       // game score, as in number apples eaten
-	level          int       // game level
-	freq           int64     // the number of moves the snake can make per second
-	action         Direction // action for changing direction
-	repeatedAction bool      // whether action is repeatedly pressed, if so, snake moves at max speed
-	lastMoveTime   time.Time // last timestamp the snake moved
+// ranking is a leaderboard category, games are ranked apart by mode, time limit, walls and cells
+type ranking struct {
+	mode    gameMode
+	limit   time.Duration // length of time-attack games
+	title   string        // name shown on the leaderboard
+	walls   string        // leaderboard mode of the games with walls
+	noWalls string        // leaderboard mode of the games without walls
+	hex     bool          // whether its games can have hex cells, they have their own leaderboard then
 }
 
-var directions = map[Direction][]float64{
-	North: {0, 1},
-	East:  {1, 0},
-	South: {0, -1},
-	West:  {-1, 0},
+// rankings are the leaderboard categories, in the order the leaderboard shows them
+var rankings = []ranking{
+	{mode: classicMode, title: "Classic", walls: "classic", noWalls: "wrap", hex: true},
+	{mode: endlessMode, title: "Endless", walls: "endless", noWalls: "endless-wrap"},
+	{mode: timeAttackMode, limit: time.Minute, title: "Time 60s", walls: "time-60", noWalls: "time-60-wrap", hex: true},
+	{mode: timeAttackMode, limit: 2 * time.Minute, title: "Time 120s", walls: "time-120", noWalls: "time-120-wrap", hex: true},
+	{mode: survivalMode, title: "Survival", walls: "survival", noWalls: "survival-wrap", hex: true},
+}
+
+// hexSuffix ends the leaderboard mode of the games with hex cells
+const hexSuffix = "-hex"
+
+// boardName returns the leaderboard mode of the ranking's games with or without walls, on square
+// or hex cells
+func (r ranking) boardName(wrap, hex bool) string {
+	name := r.walls
+	if wrap {
+		name = r.noWalls
+	}
+	if r.hex && hex {
+		name += hexSuffix
+	}
+	return name
+}
+
+// ranking returns the leaderboard category of the game, if it has one
+func (s *SnakeGame) ranking() (ranking, bool) {
+	for _, r := range rankings {
+		if r.mode == s.mode && r.limit == s.limit {
+			return r, true
+		}
+	}
+	return ranking{}, false
+}
+
+// board returns the leaderboard mode the game's score goes to, if it has one
+func (s *SnakeGame) board() (string, bool) {
+	if s.mode == dailyMode {
+		return db.DailyMode(s.day), true
+	}
+	r, ok := s.ranking()
+	return r.boardName(s.Wrap, s.Topology == grid.Hex), ok
+}
+
+// colors of each snake's body and head, by player
+var bodyColors = []color.RGBA{colornames.Limegreen, colornames.Deepskyblue, colornames.Gold, colornames.Hotpink}
+var headColors = []color.RGBA{colornames.Purple, colornames.Navy, colornames.Darkorange, colornames.Mediumvioletred}
+
+// colors of the special items, on the board and on the HUD
+var itemColors = map[engine.Kind]color.RGBA{
+	engine.Golden: colornames.Gold,
+	engine.Slow:   colornames.Deepskyblue,
+	engine.Ghost:  colornames.Silver,
+	engine.Shrink: colornames.Orchid,
+	engine.Poison: colornames.Darkolivegreen,
+}
+
+// colors of the pairs of portals, pairs beyond them take the colors again
+var portalColors = []color.RGBA{colornames.Darkviolet, colornames.Teal, colornames.Darkorange, colornames.Steelblue}
+
+// blinkSteps is when an item starts blinking before it disappears
+const blinkSteps = 10
+
+type SnakeGame struct {
+	*engine.Game            // the snakes and the apples, as the rules see them
+	state        snakeState // state indicates whether the snake should is moving
+	mode         gameMode   // kind of game
+
+	level          int                  // game level
+	freq           int64                // the number of moves the snake can make per second
+	actions        []grid.Direction     // action for changing direction, by player
+	repeatedAction bool                 // whether action is repeatedly pressed, if so, snake moves at max speed
+	lastMoveTime   time.Time            // last timestamp the snake moved
+	demo           bool                 // whether the autopilot plays, then the snake runs at max speed and levels never end
+	seed           int64                // seed of the levels and the apples of an endless game
+	limit          time.Duration        // length of a time-attack game
+	played         time.Duration        // time the snake has been moving for, pauses left out
+	lastFrame      time.Time            // when the time played was last counted, zero after a pause
+	rivals         []engine.Personality // personality of each snake after the player's in a battle
+	day            time.Time            // date of a daily challenge, its levels and apples come from it
 }
 
 // mapping from game level to freq (index 0 is not used)
 var frequencies = []int64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
 
-func newSnakeGame() *SnakeGame {
+func newSnakeGame(mode gameMode) *SnakeGame {
+	var rivals []engine.Personality
+	snakes := 1
+	switch mode {
+	case versusMode:
+		snakes = 2
+	case battleMode:
+		rivals = battleRivals()
+		snakes += len(rivals)
+	}
+	// the seed gives the whole endless game, so a game played again with it is the same
+	seed := time.Now().UnixNano()
+	if mode == dailyMode {
+		seed = dailySeed(dailyDay)
+	}
 	snakeGame := &SnakeGame{
-		alive:        true,
-		action:       East,
+		Game:         engine.New(rand.New(rand.NewSource(seed)), snakes),
+		mode:         mode,
 		lastMoveTime: time.Now(),
-		won:          false,
+		rivals:       rivals,
+		seed:         seed,
+	}
+	apples := appleCount
+	if mode == dailyMode {
+		// everyone gets the same apples on the same day, whatever their options
+		snakeGame.day = dailyDay
+		snakeGame.SetAppleSeed(seed)
+		apples = 1
 	}
+	// a level loaded puts as many apples on its board
+	snakeGame.SetAppleCount(apples)
 	snakeGame.setLevel(1)
+	switch mode {
+	case versusMode, battleMode:
+		snakeGame.freq = frequencies[versusLevel]
+	case timeAttackMode:
+		snakeGame.freq = frequencies[timeAttackLevel]
+		snakeGame.limit = timeLimit
+	}
+	// rivals that die leave food for the others
+	snakeGame.Feed = mode == battleMode
+	// special items come up in single player games
+	snakeGame.PowerUps = snakes == 1 && mode != dailyMode
+	snakeGame.Wrap = wrap && mode != dailyMode
 	snakeGame.resetSnake()
-	snakeGame.generateApple()
+	return snakeGame
+}
+
+// newDemoGame creates a game for the autopilot, it runs until the snake dies or fills the board
+func newDemoGame() *SnakeGame {
+	snakeGame := newSnakeGame(classicMode)
+	snakeGame.demo = true
+	// the autopilot and the bots don't know about items
+	snakeGame.PowerUps = false
+	snakeGame.freq = frequencies[len(frequencies)-1]
 	return snakeGame
 }
 
@@ -85,162 +210,351 @@ func (s *SnakeGame) draw(win *pixelgl.Window) {
 	atlas := text.NewAtlas(basicfont.Face7x13, text.ASCII)
 	txt := text.New(pixel.V(100, 700), atlas)
 	txt.Color = colornames.Black
-	fmt.Fprintf(txt, "Level %d: %d", s.level, s.score)
-	// compute offset, which is the lower left boundary of the allowed area
-	offsetX := (win.Bounds().W() - (Width+1)*Unit) / 2
-	offsetY := (win.Bounds().H() - txt.Bounds().H() - (Height)*Unit) / 2
-	offset := pixel.V(offsetX, offsetY)
+	switch s.mode {
+	case versusMode, battleMode:
+		for i, snake := range s.Snakes {
+			fmt.Fprintf(txt, "%s: %d  ", s.snakeName(i), snake.Score)
+		}
+	case endlessMode:
+		fmt.Fprintf(txt, "Endless - Level %d: %d", s.level, s.Score)
+	case dailyMode:
+		fmt.Fprintf(txt, "Daily %s - Level %d: %d", s.day.Format("Jan 2"), s.level, s.Score)
+	case timeAttackMode:
+		fmt.Fprintf(txt, "Time Attack - %s left: %d", formatClock(s.limit-s.played), s.Score)
+	case survivalMode:
+		fmt.Fprintf(txt, "Survival - %s - speed %.1f: %d", formatClock(s.played), s.survivalSpeed(), s.Score)
+	case practiceMode:
+		fmt.Fprint(txt, "Practice - ")
+		fallthrough
+	default:
+		if len(levels) > 0 {
+			fmt.Fprintf(txt, "%s: %d", s.Title, s.Score)
+		} else {
+			fmt.Fprintf(txt, "Level %d: %d", s.level, s.Score)
+		}
+	}
+	// the effects on the player's snake, with the steps they last
+	for _, k := range engine.Kinds {
+		if steps := s.Effect(s.Snake, k); steps > 0 {
+			txt.Color = itemColors[k]
+			fmt.Fprintf(txt, "  %v %d", k, steps)
+		}
+	}
+	l := newLayout(win.Bounds(), txt.Bounds().H(), s.Width, s.Height, s.Topology == grid.Hex)
 	// position level txt in top center
 	txt.Draw(win, pixel.IM.Moved(win.Bounds().Center().Sub(txt.Bounds().Center()).Add(pixel.V(0, win.Bounds().H()/2-txt.Bounds().H()/2))))
-	// draw wall
+	// draw wall, a grid without wall only gets a faint border
 	imd := imdraw.New(nil)
 	imd.Color = colornames.Coral
-	for i := -1; i < Width+1; i++ {
-		v := pixel.V(float64(i*Unit), -Unit)
-		imd.Push(v.Add(offset))
-		imd.Push(v.Add(unitV).Add(offset))
-		imd.Rectangle(0)
-		v = pixel.V(float64(i*Unit), float64((Height)*Unit))
-		imd.Push(v.Add(offset))
-		imd.Push(v.Add(unitV).Add(offset))
-		imd.Rectangle(0)
-	}
-	for i := 0; i < Height; i++ {
-		v := pixel.V(-Unit, float64(i*Unit))
-		imd.Push(v.Add(offset))
-		imd.Push(v.Add(unitV).Add(offset))
-		imd.Rectangle(0)
-		v = pixel.V(float64((Width)*Unit), float64(i*Unit))
-		imd.Push(v.Add(offset))
-		imd.Push(v.Add(unitV).Add(offset))
-		imd.Rectangle(0)
-	}
-	// draw snake body and head
-	imd.Color = colornames.Limegreen
-	for i := 0; i < len(s.body)-1; i++ {
-		imd.Push(pixel.V(s.body[i].X*Unit, s.body[i].Y*Unit).Add(offset))
-		imd.Push(pixel.V((s.body[i].X+1)*Unit, (s.body[i].Y+1)*Unit).Add(offset))
-		imd.Rectangle(0)
-	}
-	imd.Color = colornames.Purple
-	imd.Push(pixel.V(s.body[len(s.body)-1].X*Unit, s.body[len(s.body)-1].Y*Unit).Add(offset))
-	imd.Push(pixel.V((s.body[len(s.body)-1].X+1)*Unit, (s.body[len(s.body)-1].Y+1)*Unit).Add(offset))
-	imd.Rectangle(0)
-	// draw apple
+	if s.Wrap {
+		imd.Color = colornames.Lavender
+	}
+	for i := -1; i < s.Width+1; i++ {
+		l.drawCell(imd, grid.Point{X: i, Y: -1})
+		l.drawCell(imd, grid.Point{X: i, Y: s.Height})
+	}
+	for i := 0; i < s.Height; i++ {
+		l.drawCell(imd, grid.Point{X: -1, Y: i})
+		l.drawCell(imd, grid.Point{X: s.Width, Y: i})
+	}
+	// draw the level's walls inside the grid
+	imd.Color = colornames.Coral
+	for _, p := range s.Walls {
+		l.drawCell(imd, p)
+	}
+	// a pair of portals is a pair of rings of the same color
+	for i, pair := range s.Portals {
+		imd.Color = portalColors[i%len(portalColors)]
+		for _, p := range pair {
+			imd.Push(l.center(p))
+			imd.Circle(l.unit*0.4, l.unit/5)
+		}
+	}
+	if s.mode == practiceMode {
+		s.drawHint(imd, l)
+	}
+	// draw snake bodies and heads
+	for i, snake := range s.Snakes {
+		if len(snake.Body) == 0 {
+			// the snake turned into food
+			continue
+		}
+		imd.Color = bodyColors[i]
+		switch {
+		case !snake.Alive:
+			imd.Color = colornames.Gray
+		case snake.Ghost > 0:
+			imd.Color = itemColors[engine.Ghost]
+		}
+		for j := 0; j < len(snake.Body)-1; j++ {
+			l.drawCell(imd, snake.Body[j])
+		}
+		imd.Color = headColors[i]
+		if s.mode == practiceMode && s.Trapping(s.actions[0]) {
+			// the next step kills the snake or traps it
+			imd.Color = colornames.Orangered
+		}
+		l.drawCell(imd, snake.Head())
+	}
+	// draw apple and the food left by dead snakes
 	imd.Color = colornames.Red
-	imd.Push(pixel.V(s.apple.X*Unit, s.apple.Y*Unit).Add(offset))
-	imd.Push(pixel.V((s.apple.X+1)*Unit, (s.apple.Y+1)*Unit).Add(offset))
-	imd.Rectangle(0)
+	for _, apple := range s.Apples {
+		l.drawCell(imd, apple)
+	}
+	imd.Color = colornames.Salmon
+	for _, food := range s.Food {
+		l.drawCell(imd, food)
+	}
+	// draw the special items as dots, they blink before they disappear
+	for _, item := range s.Items {
+		if item.Left <= blinkSteps && item.Left%2 == 1 {
+			continue
+		}
+		imd.Color = itemColors[item.Kind]
+		imd.Push(l.center(item.At))
+		imd.Circle(l.unit/2, 0)
+	}
+	// draw the hazards as diamonds
+	imd.Color = colornames.Black
+	for _, h := range s.Hazards {
+		center := l.center(h.At)
+		for _, v := range []pixel.Vec{pixel.V(0, 1), pixel.V(1, 0), pixel.V(0, -1), pixel.V(-1, 0)} {
+			imd.Push(center.Add(v.Scaled(l.unit / 2)))
+		}
+		imd.Polygon(0)
+	}
 
 	imd.Draw(win)
 }
 
+// drawHint marks the shortest safe path to the apple with dots
+func (s *SnakeGame) drawHint(imd *imdraw.IMDraw, l layout) {
+	imd.Color = colornames.Lightskyblue
+	for _, p := range s.Hint() {
+		imd.Push(l.center(p))
+		imd.Circle(l.unit/5, 0)
+	}
+}
+
+// layout places the grid in the window
+type layout struct {
+	offset pixel.Vec // lower left corner of the grid
+	unit   float64   // size of a cell, or the distance between two columns of hex cells
+	hex    bool      // whether the cells are hexagons
+}
+
+// hexRise is the height of a hex cell for a unit between two columns
+var hexRise = math.Sqrt(3) / 1.5
+
+// newLayout fits a grid of width x height cells and its wall in bounds, under the HUD. Cells shrink
+// when a big level wouldn't fit in the window otherwise.
+func newLayout(bounds pixel.Rect, hud float64, width, height int, hex bool) layout {
+	if hex {
+		// the wall's hexagons stick out by a third of a unit and the odd columns by half a cell
+		unit := math.Min(Unit, math.Min(bounds.W()/(float64(width)+2+1.0/3), (bounds.H()-hud)/((float64(height)+2.5)*hexRise)))
+		offsetX := (bounds.W() - (float64(width)+1.0/3)*unit) / 2
+		offsetY := (bounds.H() - hud - (float64(height)+0.5)*unit*hexRise) / 2
+		return layout{offset: pixel.V(offsetX, offsetY), unit: unit, hex: true}
+	}
+	unit := math.Min(Unit, math.Min(bounds.W()/float64(width+2), (bounds.H()-hud)/float64(height+2)))
+	// compute offset, which is the lower left boundary of the allowed area
+	offsetX := (bounds.W() - float64(width+1)*unit) / 2
+	offsetY := (bounds.H() - hud - float64(height)*unit) / 2
+	return layout{offset: pixel.V(offsetX, offsetY), unit: unit}
+}
+
+// corner returns the lower left corner of square cell p
+func (l layout) corner(p grid.Point) pixel.Vec {
+	return pixel.V(float64(p.X)*l.unit, float64(p.Y)*l.unit).Add(l.offset)
+}
+
+// center returns the center of cell p
+func (l layout) center(p grid.Point) pixel.Vec {
+	if l.hex {
+		// odd columns are half a cell higher
+		rise := l.unit * hexRise
+		return l.offset.Add(pixel.V(float64(p.X)*l.unit+l.unit*2/3, (float64(p.Y)+0.5+float64(p.X&1)/2)*rise))
+	}
+	return l.corner(p).Add(pixel.V(l.unit, l.unit).Scaled(0.5))
+}
+
+// drawCell draws a square or a hexagon on cell p of the grid
+func (l layout) drawCell(imd *imdraw.IMDraw, p grid.Point) {
+	if l.hex {
+		center := l.center(p)
+		for i := 0; i < 6; i++ {
+			imd.Push(center.Add(pixel.V(l.unit*2/3, 0).Rotated(float64(i) * math.Pi / 3)))
+		}
+		imd.Polygon(0)
+		return
+	}
+	corner := l.corner(p)
+	imd.Push(corner)
+	imd.Push(corner.Add(pixel.V(l.unit, l.unit)))
+	imd.Rectangle(0)
+}
+
 // snake moves
 func (s *SnakeGame) move() {
 	if s.state == Idle {
 		return
 	}
-	freq := s.freq
-	if s.action == s.dir && s.repeatedAction {
-		// if a key is held, win.Repeated will return true, false, false, false, false, true, ...
-		// the max frequency is multipled by 5 to accommodate this
-		freq = frequencies[len(frequencies)-1] * 5
-	}
-	if time.Since(s.lastMoveTime).Milliseconds() > time.Second.Milliseconds()/freq {
-		// change direction if needed
-		s.dir = changeDirection(s.dir, s.action)
-		// advance head
-		head := s.body[len(s.body)-1]
-		nx, ny := head.X+directions[s.dir][0], head.Y+directions[s.dir][1]
-		// check the snake is not out of bound
-		if nx < 0 || nx >= Width || ny < 0 || ny >= Height {
-			s.alive = false
-			return
-		}
-		// check the snake is not colliding with itself
-		for _, pos := range s.body {
-			if pos.X == nx && pos.Y == ny {
-				s.alive = false
-				return
-			}
-		}
-		s.body = append(s.body, pixel.V(nx, ny))
-		// if apple is eaten, generate a new apple
-		if nx != s.apple.X || ny != s.apple.Y {
-			s.body = s.body[1:]
-		} else {
-			s.generateApple()
-			s.score += 1
-		}
+	s.countTime()
+	if s.due() {
+		s.Step(s.actions...)
+		s.publish()
 		// update last move timestamp
 		s.lastMoveTime = time.Now()
 	}
-	if s.passLevel() {
+	if s.leveled() && s.passLevel() {
 		s.advanceLevel()
 	}
 }
 
+// publish streams the game to the spectators after a step
+func (s *SnakeGame) publish() {
+	if spectators == nil {
+		return
+	}
+	spectators.Publish(s.Game)
+	if !s.Alive || s.Won || len(s.Snakes) > 1 && s.matchOver() {
+		spectators.Over(s.Game)
+	}
+}
+
+// due tells whether it's time for the snake to make its next step
+func (s *SnakeGame) due() bool {
+	freq := float64(s.freq)
+	if s.mode == survivalMode {
+		freq = s.survivalSpeed()
+	}
+	if s.actions[0] == s.Dir && s.repeatedAction {
+		// if a key is held, win.Repeated will return true, false, false, false, false, true, ...
+		// the max frequency is multipled by 5 to accommodate this
+		freq = float64(frequencies[len(frequencies)-1] * 5)
+	}
+	// slow-motion halves the speed
+	if s.Slow > 0 {
+		freq /= 2
+	}
+	return time.Since(s.lastMoveTime) > time.Duration(float64(time.Second)/freq)
+}
+
 // check whether it should advance to next level
 func (s *SnakeGame) passLevel() bool {
-	return s.score == s.level*AppleCnt
+	if s.mazes() {
+		return s.Eaten >= s.level*endlessApples
+	}
+	return s.Eaten >= s.level*AppleCnt
 }
 
-// advance to next level
+// advance to next level, endless games never end this way
 func (s *SnakeGame) advanceLevel() {
-	if s.level == MaxLevel {
-		s.won = true
+	if !s.mazes() && s.level == lastLevel() {
+		s.Won = true
 		return
 	}
 
-	s.resetSnake()
 	s.setLevel(s.level + 1)
+	s.resetSnake()
+}
+
+// lastLevel returns the level ending the campaign, a level file has as many levels as boards
+func lastLevel() int {
+	if len(levels) > 0 {
+		return len(levels)
+	}
+	return MaxLevel
 }
 
 // reset state of snake
 func (s *SnakeGame) resetSnake() {
-	s.dir = East
+	s.Reset()
 	s.state = Idle // snake starts as idle, waiting from command
-	s.body = []pixel.Vec{pixel.V(0, Height/2), pixel.V(1, Height/2), pixel.V(2, Height/2)}
+	s.lastFrame = time.Time{}
+	s.actions = nil
+	for _, snake := range s.Snakes {
+		s.actions = append(s.actions, snake.Dir)
+	}
 }
 
 // set game level
 func (s *SnakeGame) setLevel(level int) {
 	s.level = level
-	s.freq = frequencies[s.level]
+	s.freq = frequencies[min(s.level, len(frequencies)-1)]
+	switch {
+	case len(levels) > 0 && (s.mode == classicMode || s.mode == practiceMode):
+		// the level file gives each level of the campaign its board
+		s.Load(levels[(level-1)%len(levels)])
+	case s.mazes():
+		s.Load(engine.Maze(s.seed, level))
+	case hexGrid && s.players() == 1 && s.mode != battleMode && s.Level != engine.HexLevel:
+		// the other single player games are played on the empty board, which may have hex cells
+		s.Load(engine.HexLevel)
+	}
 }
 
-// change direction
-func changeDirection(dir Direction, action Direction) Direction {
-	if dir == action {
-		return dir
-	}
-	if math.Abs(float64(dir)-float64(action)) == 2 {
-		return dir
+// keys returns the keys steering player i's snake
+func (s *SnakeGame) keys(i int) []steerKey {
+	if s.Topology == grid.Hex {
+		return hexKeys
 	}
-	return action
+	return playerKeys[i]
+}
+
+// check whether the snake is in an invalid position
+func (s *SnakeGame) dead(win *pixelgl.Window) bool {
+	return !s.Alive
 }
 
-// generate apple randomly
-func (s *SnakeGame) generateApple() {
-	for {
-		x := rand.Intn(Width)
-		y := rand.Intn(Height)
-		// check collision
-		hit := false
-		for _, pos := range s.body {
-			if int(pos.X) == x && int(pos.Y) == y {
-				hit = true
-				break
-			}
+// matchOver tells whether a game between several snakes has ended. A versus game ends as soon as a
+// snake dies, a battle when the player dies or is the last one alive. Both end when the board is full.
+func (s *SnakeGame) matchOver() bool {
+	if s.mode == battleMode {
+		return !s.Alive || s.Survivors() == 1 || s.Won
+	}
+	for _, snake := range s.Snakes {
+		if !snake.Alive {
+			return true
 		}
-		if !hit {
-			s.apple = pixel.V(float64(x), float64(y))
-			break
+	}
+	return s.Won
+}
+
+// leveled tells whether the game goes through the levels
+func (s *SnakeGame) leveled() bool {
+	return !s.demo && (s.mode == classicMode || s.mode == practiceMode || s.mazes())
+}
+
+// mazes tells whether the game goes through maze levels generated from its seed, with no end
+func (s *SnakeGame) mazes() bool {
+	return s.mode == endlessMode || s.mode == dailyMode
+}
+
+// players returns the number of snakes steered from the keyboard
+func (s *SnakeGame) players() int {
+	if s.mode == versusMode {
+		return 2
+	}
+	return 1
+}
+
+// steerRivals lets the computer choose where the rivals go on the next step
+func (s *SnakeGame) steerRivals() {
+	for i, personality := range s.rivals {
+		if s.Snakes[i+1].Alive {
+			s.actions[i+1] = s.Steer(i+1, personality)
 		}
 	}
 }
 
-// check whether the snake is in an invalid position
-func (s *SnakeGame) dead(win *pixelgl.Window) bool {
-	return !s.alive
+// snakeName returns how snake i is called in the scores
+func (s *SnakeGame) snakeName(i int) string {
+	switch {
+	case s.mode != battleMode:
+		return fmt.Sprintf("P%d", i+1)
+	case i == 0:
+		return "You"
+	}
+	return fmt.Sprintf("%v %d", s.rivals[i-1], i)
 }
@@ -0,0 +1,140 @@
+// timed.go sets up the games that are about time: time attack, where the snake has a fixed time to
+// eat, and survival, where it goes faster all the time
+
+package snake
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/colornames"
+	"golang.org/x/image/font/basicfont"
+)
+
+// timeAttackLevel sets the speed of time-attack games, which have no levels
+const timeAttackLevel = 6
+
+// speed of survival games in moves per second, it grows by survivalRamp every second played
+const (
+	survivalStart = 3.0
+	survivalRamp  = 1.0 / 15
+	survivalMax   = 15.0
+)
+
+// timeLimits are the lengths of time-attack games the time-attack menu cycles through
+var timeLimits = []time.Duration{time.Minute, 2 * time.Minute}
+
+var timeLimit = timeLimits[0] // length of the next time-attack games
+
+var timeLimitButton *RectButton // time-attack menu button choosing the length of the games
+
+var summaryMenu *Menu
+
+func createTimeAttackMenu() *Menu {
+	menu := newMenu()
+	// add buttons for time-attack menu, the first one cycles through the lengths
+	rect := pixel.Rect{Min: pixel.V(200, 270), Max: pixel.V(300, 300)}
+	timeLimitButton = newRectButton(rect, timeLimitLabel(), false, timeLimitHandler)
+	menu.addButton(timeLimitButton)
+	rect = pixel.Rect{Min: pixel.V(200, 230), Max: pixel.V(300, 260)}
+	menu.addButton(newRectButton(rect, startButtonName, false, startTimeAttackHandler))
+	rect = pixel.Rect{Min: pixel.V(200, 190), Max: pixel.V(300, 220)}
+	menu.addButton(newRectButton(rect, backButtonName, false, backHandler))
+	return menu
+}
+
+func timeLimitLabel() string {
+	return fmt.Sprintf("Time: %ds", int(timeLimit.Seconds()))
+}
+
+func timeLimitHandler() {
+	for i, limit := range timeLimits {
+		if limit == timeLimit {
+			timeLimit = timeLimits[(i+1)%len(timeLimits)]
+			break
+		}
+	}
+	timeLimitButton.msg = timeLimitLabel()
+}
+
+func startTimeAttackHandler() {
+	mode = timeAttackMode
+	startGame()
+}
+
+func createSummaryMenu() *Menu {
+	menu := newMenu()
+	// the summary text is set when a timed game ends
+	rect := pixel.Rect{Min: pixel.V(200, 270), Max: pixel.V(300, 300)}
+	menu.addButton(newRectButton(rect, playAgainButtonName, false, playAgainHandler))
+	rect = pixel.Rect{Min: pixel.V(200, 230), Max: pixel.V(300, 260)}
+	menu.addButton(newRectButton(rect, mainMenuButtonName, false, mainMenuHandler))
+	return menu
+}
+
+// showSummary shows how the time-attack or survival game went
+func showSummary(win *pixelgl.Window, snakeGame *SnakeGame) {
+	atlas := text.NewAtlas(basicfont.Face7x13, text.ASCII)
+	txt := text.New(pixel.V(100, 700), atlas)
+	txt.Color = colornames.Red
+	if snakeGame.Alive {
+		fmt.Fprintln(txt, "Time's Up!")
+	} else {
+		fmt.Fprintln(txt, "Game Over")
+	}
+	fmt.Fprintf(txt, "Score: %d\n", snakeGame.Score)
+	fmt.Fprintf(txt, "Apples: %d\n", snakeGame.Eaten)
+	if snakeGame.mode == survivalMode {
+		fmt.Fprintf(txt, "Survived: %s\n", formatClock(snakeGame.played))
+		fmt.Fprintf(txt, "Top speed: %.1f\n", snakeGame.survivalSpeed())
+	} else {
+		fmt.Fprintf(txt, "Time: %s of %s\n", formatClock(snakeGame.played), formatClock(snakeGame.limit))
+	}
+	matrix := pixel.IM.Moved(win.Bounds().Center().Sub(txt.Bounds().Center()).Add(pixel.V(0, win.Bounds().H()/2-txt.Bounds().H()/2)))
+
+	summaryMenu.texts = nil
+	summaryMenu.textMatrices = nil
+	summaryMenu.addText(txt, matrix)
+	menuStack = append(menuStack, summaryMenu)
+}
+
+// timed tells whether the game ends on the summary screen
+func (s *SnakeGame) timed() bool {
+	return s.mode == timeAttackMode || s.mode == survivalMode
+}
+
+// timeUp tells whether a time-attack game has run out of time
+func (s *SnakeGame) timeUp() bool {
+	return s.mode == timeAttackMode && s.played >= s.limit
+}
+
+// survivalSpeed returns the number of moves per second of a survival game at the time played
+func (s *SnakeGame) survivalSpeed() float64 {
+	speed := survivalStart + s.played.Seconds()*survivalRamp
+	if speed > survivalMax {
+		return survivalMax
+	}
+	return speed
+}
+
+// countTime adds the time since the last frame to the time played, it's called on every frame
+// the snake moves
+func (s *SnakeGame) countTime() {
+	now := time.Now()
+	if !s.lastFrame.IsZero() {
+		s.played += now.Sub(s.lastFrame)
+	}
+	s.lastFrame = now
+}
+
+// formatClock returns d as minutes and seconds, e.g. 1:05
+func formatClock(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	seconds := int(d.Seconds())
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
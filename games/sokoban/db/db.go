@@ -0,0 +1,91 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const dbName = "./.game.db"
+
+var gameDB *sql.DB
+
+// errNotOpen is returned when the db couldn't be opened, the game goes on without records then
+var errNotOpen = errors.New("db is not open")
+
+// Record is the best result of a solved level
+type Record struct {
+	Moves    int
+	Pushes   int
+	Solution string // solution in LURD notation
+}
+
+func Open() error {
+	db, err := sql.Open("sqlite3", dbName)
+	if err != nil {
+		return err
+	}
+	// create table, levels are identified by the collection name and their 1-based index in it
+	sqlStmt := `create table if not exists sokoban (
+		collection text not null,
+		level integer not null,
+		moves integer not null,
+		pushes integer not null,
+		solution text not null,
+		primary key (collection, level)
+	);`
+	_, err = db.Exec(sqlStmt)
+	if err != nil {
+		return err
+	}
+	gameDB = db
+	return nil
+}
+
+// Save stores the result of a solved level, keeping the previous one if it took fewer moves
+func Save(collection string, level int, record Record) error {
+	stmt := `insert into sokoban(collection, level, moves, pushes, solution) values(?, ?, ?, ?, ?)
+		on conflict(collection, level) do update set moves = excluded.moves, pushes = excluded.pushes, solution = excluded.solution
+		where excluded.moves < sokoban.moves or (excluded.moves = sokoban.moves and excluded.pushes < sokoban.pushes)`
+	if gameDB == nil {
+		return errNotOpen
+	}
+	_, err := gameDB.Exec(stmt, collection, level, record.Moves, record.Pushes, record.Solution)
+	return err
+}
+
+// Read returns the records of the solved levels in a collection keyed by level index
+func Read(collection string) (map[int]Record, error) {
+	if gameDB == nil {
+		return nil, errNotOpen
+	}
+	rows, err := gameDB.Query("select level, moves, pushes, solution from sokoban where collection = ?", collection)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := make(map[int]Record)
+	for rows.Next() {
+		var level int
+		var record Record
+		err = rows.Scan(&level, &record.Moves, &record.Pushes, &record.Solution)
+		if err != nil {
+			return nil, err
+		}
+		records[level] = record
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func Close() error {
+	if gameDB != nil {
+		return gameDB.Close()
+	}
+	return nil
+}
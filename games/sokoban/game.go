@@ -0,0 +1,196 @@
+package sokoban
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type Direction int
+
+const (
+	Up Direction = iota
+	Right
+	Down
+	Left
+)
+
+// deltas are (dx, dy) per direction, y grows downwards as rows do in XSB
+var deltas = [][]int{
+	Up:    {0, -1},
+	Right: {1, 0},
+	Down:  {0, 1},
+	Left:  {-1, 0},
+}
+
+// LURD notation: lower case letters are moves, upper case letters are pushes
+var lurdMoves = []byte{
+	Up:    'u',
+	Right: 'r',
+	Down:  'd',
+	Left:  'l',
+}
+
+type Game struct {
+	level  *Level
+	player int    // player position as cell index
+	boxes  []bool // whether a cell holds a box
+	moves  int    // number of player moves, pushes included
+	pushes int    // number of box pushes
+
+	history []byte // performed steps in LURD notation
+	redo    []byte // undone steps in LURD notation, the next step to redo is the last one
+}
+
+func newGame(level *Level) *Game {
+	g := &Game{level: level}
+	g.reset()
+	return g
+}
+
+// reset puts the player and boxes back to the start of the level
+func (g *Game) reset() {
+	g.player = g.level.player
+	g.boxes = make([]bool, len(g.level.walls))
+	for _, box := range g.level.boxes {
+		g.boxes[box] = true
+	}
+	g.moves = 0
+	g.pushes = 0
+	g.history = nil
+	g.redo = nil
+}
+
+// neighbor returns the cell next to i in direction dir, or -1 if it's off the board
+func (g *Game) neighbor(i int, dir Direction) int {
+	x, y := g.level.coords(i)
+	nx, ny := x+deltas[dir][0], y+deltas[dir][1]
+	if nx < 0 || nx >= g.level.Width || ny < 0 || ny >= g.level.Height {
+		return -1
+	}
+	return g.level.index(nx, ny)
+}
+
+// free reports whether a box or the player can be moved onto cell i
+func (g *Game) free(i int) bool {
+	return i != -1 && !g.level.walls[i] && !g.boxes[i]
+}
+
+// move moves the player in direction dir, pushing a box if there is one.
+// It returns false if the move is blocked.
+func (g *Game) move(dir Direction) bool {
+	if !g.step(dir) {
+		return false
+	}
+	step := g.history[len(g.history)-1]
+	// keep the redo stack if the player repeats the undone step, otherwise drop it
+	if len(g.redo) > 0 && g.redo[len(g.redo)-1] == step {
+		g.redo = g.redo[:len(g.redo)-1]
+	} else {
+		g.redo = nil
+	}
+	return true
+}
+
+// step performs a move without touching the redo stack
+func (g *Game) step(dir Direction) bool {
+	next := g.neighbor(g.player, dir)
+	if next == -1 || g.level.walls[next] {
+		return false
+	}
+	lurd := lurdMoves[dir]
+	if g.boxes[next] {
+		target := g.neighbor(next, dir)
+		if !g.free(target) {
+			return false
+		}
+		g.boxes[next] = false
+		g.boxes[target] = true
+		g.pushes++
+		lurd = byte(unicode.ToUpper(rune(lurd)))
+	}
+	g.player = next
+	g.moves++
+	g.history = append(g.history, lurd)
+	return true
+}
+
+// undo reverts the last step, it returns false if there is nothing to undo
+func (g *Game) undo() bool {
+	if len(g.history) == 0 {
+		return false
+	}
+	lurd := g.history[len(g.history)-1]
+	g.history = g.history[:len(g.history)-1]
+	dir := lurdDirection(lurd)
+	prev := g.neighbor(g.player, opposite(dir))
+	if unicode.IsUpper(rune(lurd)) {
+		box := g.neighbor(g.player, dir)
+		g.boxes[box] = false
+		g.boxes[g.player] = true
+		g.pushes--
+	}
+	g.player = prev
+	g.moves--
+	g.redo = append(g.redo, lurd)
+	return true
+}
+
+// redoStep performs the last undone step again, it returns false if there is nothing to redo
+func (g *Game) redoStep() bool {
+	if len(g.redo) == 0 {
+		return false
+	}
+	lurd := g.redo[len(g.redo)-1]
+	g.redo = g.redo[:len(g.redo)-1]
+	return g.step(lurdDirection(lurd))
+}
+
+// solved reports whether every box is on a goal
+func (g *Game) solved() bool {
+	for i, box := range g.boxes {
+		if box && !g.level.goals[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// solution returns the steps performed so far in LURD notation
+func (g *Game) solution() string {
+	return string(g.history)
+}
+
+// replay plays a LURD string from the current position. The case of each letter must
+// match whether the step is a push, so a recorded solution can be verified.
+func (g *Game) replay(lurd string) error {
+	for i, c := range strings.TrimSpace(lurd) {
+		dir := lurdDirection(byte(c))
+		if dir < 0 {
+			return fmt.Errorf("invalid LURD character %q at %d", c, i+1)
+		}
+		pushes := g.pushes
+		if !g.move(dir) {
+			return fmt.Errorf("step %d (%c) is blocked", i+1, c)
+		}
+		if (g.pushes > pushes) != unicode.IsUpper(c) {
+			return fmt.Errorf("step %d (%c) does not match the board", i+1, c)
+		}
+	}
+	return nil
+}
+
+// lurdDirection maps a LURD character to its direction, or -1 if it's not a LURD character
+func lurdDirection(c byte) Direction {
+	lower := byte(unicode.ToLower(rune(c)))
+	for dir, m := range lurdMoves {
+		if m == lower {
+			return Direction(dir)
+		}
+	}
+	return -1
+}
+
+func opposite(dir Direction) Direction {
+	return (dir + 2) % 4
+}
@@ -0,0 +1,38 @@
+package sokoban
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReplay(t *testing.T) {
+	levels, err := ParseLevels(strings.NewReader("#######\n#@ $ .#\n#######\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		lurd   string
+		err    string
+		solved bool
+	}{
+		{"rRR", "", true},
+		{" rRR\n", "", true},
+		{"rR", "", false},
+		{"u", "step 1 (u) is blocked", false},
+		{"rr", "step 2 (r) does not match the board", false},
+		{"R", "step 1 (R) does not match the board", false},
+		{"x", "invalid LURD character 'x' at 1", false},
+		{"rRx", "invalid LURD character 'x' at 3", false},
+		{"r R", "invalid LURD character ' ' at 2", false},
+	}
+	for _, tt := range tests {
+		g := newGame(levels[0])
+		err := g.replay(tt.lurd)
+		if tt.err == "" && err != nil || tt.err != "" && (err == nil || err.Error() != tt.err) {
+			t.Errorf("replay(%q) = %v, want %q", tt.lurd, err, tt.err)
+		}
+		if g.solved() != tt.solved {
+			t.Errorf("replay(%q) solved the level: %v, want %v", tt.lurd, g.solved(), tt.solved)
+		}
+	}
+}
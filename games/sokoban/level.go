@@ -0,0 +1,243 @@
+// level.go parses levels in the XSB/.sok text format
+
+package sokoban
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// XSB board characters, floor can also be written as '-' or '_'
+const (
+	wallChar       = '#'
+	playerChar     = '@'
+	playerGoalChar = '+'
+	boxChar        = '$'
+	boxGoalChar    = '*'
+	goalChar       = '.'
+	rowSeparator   = '|'
+	boardChars     = "#@+$*. -_|"
+	titlePrefix    = "title:"
+	commentPrefix  = ";"
+	maxLevelSize   = 64 // max width or height of a level
+)
+
+type Level struct {
+	Title  string
+	Width  int
+	Height int
+
+	walls  []bool // row-major, row 0 is the top row of the level
+	goals  []bool // cells that boxes must be pushed onto
+	floor  []bool // cells reachable from the player's start, drawn as floor
+	boxes  []int  // initial box positions as cell indexes
+	player int    // initial player position as cell index
+}
+
+// index returns the cell index of (x, y), y counts rows from the top
+func (l *Level) index(x, y int) int {
+	return y*l.Width + x
+}
+
+// coords returns (x, y) of the cell index
+func (l *Level) coords(i int) (int, int) {
+	return i % l.Width, i / l.Width
+}
+
+// ParseLevels reads a collection of levels. Levels are separated by any non-board line.
+// A comment line starting with ';' right before a board is used as the title of that board,
+// a "Title: " line after a board overrides it.
+func ParseLevels(r io.Reader) ([]*Level, error) {
+	var levels []*Level
+	var rows []string
+	var pendingTitle string
+	lineNo := 0
+
+	flush := func() error {
+		if len(rows) == 0 {
+			return nil
+		}
+		level, err := newLevel(rows)
+		if err != nil {
+			return fmt.Errorf("level %d (ending at line %d): %v", len(levels)+1, lineNo-1, err)
+		}
+		level.Title = pendingTitle
+		if level.Title == "" {
+			level.Title = fmt.Sprintf("Level %d", len(levels)+1)
+		}
+		levels = append(levels, level)
+		rows = nil
+		pendingTitle = ""
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if isBoardLine(line) {
+			expanded, err := expandRunLength(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNo, err)
+			}
+			rows = append(rows, strings.Split(expanded, string(rowSeparator))...)
+			continue
+		}
+		if err := flush(); err != nil {
+			return nil, err
+		}
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(strings.ToLower(trimmed), titlePrefix):
+			title := strings.TrimSpace(trimmed[len(titlePrefix):])
+			if len(levels) > 0 && title != "" {
+				levels[len(levels)-1].Title = title
+			}
+		case strings.HasPrefix(trimmed, commentPrefix):
+			pendingTitle = strings.TrimSpace(strings.TrimPrefix(trimmed, commentPrefix))
+		case trimmed != "":
+			// any other text, e.g. author or description, is metadata and ignored
+		}
+	}
+	lineNo++
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	if len(levels) == 0 {
+		return nil, fmt.Errorf("no levels found")
+	}
+	return levels, nil
+}
+
+// isBoardLine reports whether the line is a row of a board. It must contain a wall
+// and consist only of board characters and run-length digits.
+func isBoardLine(line string) bool {
+	if !strings.ContainsRune(line, wallChar) {
+		return false
+	}
+	for _, c := range line {
+		switch {
+		case strings.ContainsRune(boardChars, c):
+		case c >= '0' && c <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// expandRunLength expands run-length encoded rows, e.g. "3#-2$" becomes "###-$$"
+func expandRunLength(line string) (string, error) {
+	if !strings.ContainsAny(line, "0123456789") {
+		return line, nil
+	}
+	var sb strings.Builder
+	count := 0
+	for _, c := range line {
+		if c >= '0' && c <= '9' {
+			count = count*10 + int(c-'0')
+			if count > maxLevelSize {
+				return "", fmt.Errorf("run length %d is larger than %d", count, maxLevelSize)
+			}
+			continue
+		}
+		if count == 0 {
+			count = 1
+		}
+		sb.WriteString(strings.Repeat(string(c), count))
+		count = 0
+	}
+	if count != 0 {
+		return "", fmt.Errorf("run length is not followed by a board character")
+	}
+	return sb.String(), nil
+}
+
+// newLevel builds a level from its board rows and validates it
+func newLevel(rows []string) (*Level, error) {
+	level := &Level{Height: len(rows), player: -1}
+	for _, row := range rows {
+		level.Width = max(level.Width, len(row))
+	}
+	if level.Width > maxLevelSize || level.Height > maxLevelSize {
+		return nil, fmt.Errorf("level is %dx%d, the max size is %dx%d", level.Width, level.Height, maxLevelSize, maxLevelSize)
+	}
+	size := level.Width * level.Height
+	level.walls = make([]bool, size)
+	level.goals = make([]bool, size)
+	level.floor = make([]bool, size)
+	for y, row := range rows {
+		for x, c := range row {
+			i := level.index(x, y)
+			switch c {
+			case wallChar:
+				level.walls[i] = true
+			case playerChar, playerGoalChar:
+				if level.player != -1 {
+					return nil, fmt.Errorf("more than one player at row %d, column %d", y+1, x+1)
+				}
+				level.player = i
+			case boxChar, boxGoalChar:
+				level.boxes = append(level.boxes, i)
+			}
+			if c == playerGoalChar || c == boxGoalChar || c == goalChar {
+				level.goals[i] = true
+			}
+		}
+	}
+	if level.player == -1 {
+		return nil, fmt.Errorf("no player")
+	}
+	goals := 0
+	for _, goal := range level.goals {
+		if goal {
+			goals++
+		}
+	}
+	if len(level.boxes) == 0 {
+		return nil, fmt.Errorf("no boxes")
+	}
+	if len(level.boxes) != goals {
+		return nil, fmt.Errorf("%d boxes but %d goals", len(level.boxes), goals)
+	}
+	if !level.fillFloor() {
+		return nil, fmt.Errorf("the player is not enclosed by walls")
+	}
+	for _, box := range level.boxes {
+		if !level.floor[box] {
+			x, y := level.coords(box)
+			return nil, fmt.Errorf("box at row %d, column %d is outside the walls", y+1, x+1)
+		}
+	}
+	return level, nil
+}
+
+// fillFloor marks every cell reachable from the player as floor,
+// it returns false if the player can walk off the board
+func (l *Level) fillFloor() bool {
+	stack := []int{l.player}
+	l.floor[l.player] = true
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		x, y := l.coords(i)
+		for _, d := range deltas {
+			nx, ny := x+d[0], y+d[1]
+			if nx < 0 || nx >= l.Width || ny < 0 || ny >= l.Height {
+				return false
+			}
+			n := l.index(nx, ny)
+			if l.walls[n] || l.floor[n] {
+				continue
+			}
+			l.floor[n] = true
+			stack = append(stack, n)
+		}
+	}
+	return true
+}
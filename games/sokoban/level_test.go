@@ -0,0 +1,77 @@
+package sokoban
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseLevels(t *testing.T) {
+	const collection = `; First
+#####
+#@$.#
+#####
+
+4#|#@*#|4#
+Title: Run length
+
+  ####
+###  #
+#.$@ #
+######
+Author: someone
+`
+	levels, err := ParseLevels(strings.NewReader(collection))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		title         string
+		width, height int
+		boxes         int
+		playerX       int
+		playerY       int
+	}{
+		{"First", 5, 3, 1, 1, 1},
+		{"Run length", 4, 3, 1, 1, 1},
+		{"Level 3", 6, 4, 1, 3, 2},
+	}
+	if len(levels) != len(tests) {
+		t.Fatalf("got %d levels, want %d", len(levels), len(tests))
+	}
+	for i, tt := range tests {
+		l := levels[i]
+		x, y := l.coords(l.player)
+		if l.Title != tt.title || l.Width != tt.width || l.Height != tt.height || len(l.boxes) != tt.boxes ||
+			x != tt.playerX || y != tt.playerY {
+			t.Errorf("level %d is %q %dx%d with %d boxes and the player at %d,%d, want %q %dx%d with %d boxes and the player at %d,%d",
+				i+1, l.Title, l.Width, l.Height, len(l.boxes), x, y, tt.title, tt.width, tt.height, tt.boxes, tt.playerX, tt.playerY)
+		}
+	}
+}
+
+func TestParseLevelsErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"no levels", "; just a comment\n", "no levels found"},
+		{"two players", "######\n#@$.@#\n######\n", "level 1 (ending at line 3): more than one player at row 2, column 5"},
+		{"no player", "#####\n# $.#\n#####\n", "no player"},
+		{"no boxes", "#####\n#@ .#\n#####\n", "no boxes"},
+		{"boxes and goals", "#####\n#@$.#\n#####\n\n#####\n#@$$#\n#.###\n#####\n", "level 2 (ending at line 8): 2 boxes but 1 goals"},
+		{"open row", "#####\n#@$. \n#####\n", "the player is not enclosed by walls"},
+		{"box outside", "#####\n#@ .# $\n#####\n", "box at row 2, column 7 is outside the walls"},
+		{"long run", "#####\n#@$.#\n65#\n", "line 3: run length 65 is larger than 64"},
+		{"dangling run", "#####\n#@$.#\n#####3\n", "line 3: run length is not followed by a board character"},
+		{"too wide", strings.Repeat("#", 65) + "\n", "level is 65x1, the max size is 64x64"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseLevels(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
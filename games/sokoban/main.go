@@ -0,0 +1,138 @@
+package sokoban
+
+import (
+	_ "embed"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"github.com/miluchen/games-in-go/games/sokoban/db"
+	"golang.org/x/image/colornames"
+	"golang.org/x/image/font/basicfont"
+)
+
+type GameState int
+
+const (
+	InGame GameState = iota
+	Exit
+)
+
+//go:embed levels/default.sok
+var defaultLevels string
+
+const defaultCollection = "default"
+
+var atlas = text.NewAtlas(basicfont.Face7x13, text.ASCII)
+
+var gameState GameState
+var currentScene *Scene
+var levelMenu *LevelMenu
+
+var levelFile string          // level file given on the command line, the built-in levels are used if empty
+var collection string         // name of the level collection, used as key for completion records
+var levels []*Level           // levels of the collection
+var records map[int]db.Record // completion records of the collection keyed by 1-based level index
+
+// loadLevels reads the level collection
+func loadLevels() error {
+	if levelFile == "" {
+		collection = defaultCollection
+		var err error
+		levels, err = ParseLevels(strings.NewReader(defaultLevels))
+		return err
+	}
+	f, err := os.Open(levelFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	collection = filepath.Base(levelFile)
+	levels, err = ParseLevels(f)
+	if err != nil {
+		return fmt.Errorf("%s: %v", levelFile, err)
+	}
+	return nil
+}
+
+// loadRecords reads completion records of the collection from db
+func loadRecords() {
+	var err error
+	records, err = db.Read(collection)
+	if err != nil {
+		log.Printf("read records failed: %v\n", err)
+		records = make(map[int]db.Record)
+	}
+}
+
+func initialize() error {
+	gameState = InGame
+	currentScene = nil
+	if err := loadLevels(); err != nil {
+		return err
+	}
+	// open DB, the game is still playable without it but completion is not tracked
+	if err := db.Open(); err != nil {
+		log.Printf("open db failed: %v\n", err)
+	}
+	loadRecords()
+	levelMenu = newLevelMenu()
+	return nil
+}
+
+func close() {
+	if err := db.Close(); err != nil {
+		log.Printf("close db failed: %v\n", err)
+	}
+}
+
+// startLevel starts the level at index (0-based) of the collection
+func startLevel(index int) {
+	currentScene = newScene(index)
+}
+
+// backToMenu leaves the current level and shows the level select menu
+func backToMenu() {
+	currentScene = nil
+	levelMenu.refresh()
+}
+
+func run() {
+	if err := initialize(); err != nil {
+		log.Printf("load levels failed: %v\n", err)
+		return
+	}
+	// initialize window
+	cfg := pixelgl.WindowConfig{
+		Title:  "sokoban",
+		Bounds: pixel.R(0, 0, 640, 480),
+		VSync:  true,
+	}
+	win, err := pixelgl.NewWindow(cfg)
+	if err != nil {
+		panic(err)
+	}
+	// game loop
+	for !win.Closed() && gameState != Exit {
+		win.Clear(colornames.Black)
+		if currentScene != nil {
+			currentScene.update(win)
+		} else {
+			levelMenu.update(win)
+		}
+		win.Update()
+	}
+	close()
+}
+
+// Run starts sokoban with the levels in file, which can hold a single level or a
+// collection in XSB/.sok format. The built-in collection is used if file is empty.
+func Run(file string) {
+	levelFile = file
+	pixelgl.Run(run)
+}
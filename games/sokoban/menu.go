@@ -0,0 +1,90 @@
+package sokoban
+
+import (
+	"fmt"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/colornames"
+)
+
+const visibleLevels = 20 // number of levels listed at once
+
+// LevelMenu lists the levels of the collection with their completion
+type LevelMenu struct {
+	index int // 0-based index of the highlighted level
+	top   int // index of the first listed level
+}
+
+func newLevelMenu() *LevelMenu {
+	m := &LevelMenu{}
+	m.refresh()
+	return m
+}
+
+// refresh highlights the first unsolved level
+func (m *LevelMenu) refresh() {
+	m.index = 0
+	for m.index < len(levels)-1 {
+		if _, ok := records[m.index+1]; !ok {
+			break
+		}
+		m.index++
+	}
+	m.scroll()
+}
+
+// scroll keeps the highlighted level visible
+func (m *LevelMenu) scroll() {
+	if m.index < m.top {
+		m.top = m.index
+	} else if m.index >= m.top+visibleLevels {
+		m.top = m.index - visibleLevels + 1
+	}
+}
+
+func (m *LevelMenu) update(win *pixelgl.Window) {
+	if win.JustPressed(pixelgl.KeyEscape) {
+		gameState = Exit
+		return
+	}
+	if win.JustPressed(pixelgl.KeyEnter) {
+		startLevel(m.index)
+		return
+	}
+	if win.JustPressed(pixelgl.KeyUp) || win.Repeated(pixelgl.KeyUp) {
+		m.index = max(0, m.index-1)
+	} else if win.JustPressed(pixelgl.KeyDown) || win.Repeated(pixelgl.KeyDown) {
+		m.index = min(len(levels)-1, m.index+1)
+	} else if win.JustPressed(pixelgl.KeyPageUp) {
+		m.index = max(0, m.index-visibleLevels)
+	} else if win.JustPressed(pixelgl.KeyPageDown) {
+		m.index = min(len(levels)-1, m.index+visibleLevels)
+	}
+	m.scroll()
+	m.draw(win)
+}
+
+func (m *LevelMenu) draw(win *pixelgl.Window) {
+	win.Clear(colornames.Gray)
+	txt := text.New(pixel.ZV, atlas)
+	txt.Color = colornames.Green
+	fmt.Fprintf(txt, "Sokoban - %s (%d/%d solved)\n\n", collection, len(records), len(levels))
+	for i := m.top; i < min(len(levels), m.top+visibleLevels); i++ {
+		txt.Color = colornames.White
+		if i == m.index {
+			txt.Color = colornames.Blue
+		}
+		mark := " "
+		best := ""
+		if record, ok := records[i+1]; ok {
+			mark = "x"
+			best = fmt.Sprintf("  best: %d moves, %d pushes", record.Moves, record.Pushes)
+		}
+		fmt.Fprintf(txt, "[%s] %3d %s%s\n", mark, i+1, levels[i].Title, best)
+	}
+	txt.Color = colornames.Lightgray
+	fmt.Fprint(txt, "\nUp/Down: choose  Enter: play  Esc: exit")
+	txt.Draw(win, pixel.IM.Moved(pixel.V(20, win.Bounds().H()-txt.LineHeight-20)))
+}
@@ -0,0 +1,147 @@
+package sokoban
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"github.com/miluchen/games-in-go/games/sokoban/db"
+	"golang.org/x/image/colornames"
+)
+
+const maxUnit = 40 // max size of a square in pixels
+
+// keys that move the player
+var moveKeys = map[pixelgl.Button]Direction{
+	pixelgl.KeyUp:    Up,
+	pixelgl.KeyRight: Right,
+	pixelgl.KeyDown:  Down,
+	pixelgl.KeyLeft:  Left,
+}
+
+type Scene struct {
+	index  int   // 0-based index of the level in the collection
+	game   *Game // state of the level being played
+	solved bool  // whether the level is solved, moves are disabled then
+}
+
+func newScene(index int) *Scene {
+	return &Scene{index: index, game: newGame(levels[index])}
+}
+
+func (s *Scene) update(win *pixelgl.Window) {
+	if win.JustPressed(pixelgl.KeyEscape) {
+		backToMenu()
+		return
+	}
+	if s.solved {
+		if win.JustPressed(pixelgl.KeyEnter) {
+			if s.index+1 < len(levels) {
+				startLevel(s.index + 1)
+			} else {
+				backToMenu()
+			}
+			return
+		}
+	} else {
+		s.handleInput(win)
+		if s.game.solved() {
+			s.solved = true
+			s.record()
+		}
+	}
+	s.draw(win)
+}
+
+// handleInput moves the player or undoes/redoes/restarts. Holding a key repeats it.
+func (s *Scene) handleInput(win *pixelgl.Window) {
+	for key, dir := range moveKeys {
+		if win.JustPressed(key) || win.Repeated(key) {
+			s.game.move(dir)
+			return
+		}
+	}
+	if win.JustPressed(pixelgl.KeyZ) || win.Repeated(pixelgl.KeyZ) || win.JustPressed(pixelgl.KeyBackspace) {
+		s.game.undo()
+	} else if win.JustPressed(pixelgl.KeyY) || win.Repeated(pixelgl.KeyY) {
+		s.game.redoStep()
+	} else if win.JustPressed(pixelgl.KeyR) {
+		s.game.reset()
+	}
+}
+
+// record stores the completion of the level in db
+func (s *Scene) record() {
+	record := db.Record{Moves: s.game.moves, Pushes: s.game.pushes, Solution: s.game.solution()}
+	log.Printf("%s solved in %d moves, %d pushes: %s\n", levels[s.index].Title, record.Moves, record.Pushes, record.Solution)
+	if err := db.Save(collection, s.index+1, record); err != nil {
+		log.Printf("save record failed: %v\n", err)
+	}
+	loadRecords()
+}
+
+func (s *Scene) draw(win *pixelgl.Window) {
+	win.Clear(colornames.Darkslategray)
+	level := s.game.level
+	// draw status text on top
+	txt := text.New(pixel.ZV, atlas)
+	txt.Color = colornames.White
+	fmt.Fprintf(txt, "%d/%d %s   Moves: %d  Pushes: %d", s.index+1, len(levels), level.Title, s.game.moves, s.game.pushes)
+	txt.Draw(win, pixel.IM.Moved(pixel.V(10, win.Bounds().H()-txt.Bounds().H()-5)))
+	help := text.New(pixel.ZV, atlas)
+	help.Color = colornames.Lightgray
+	if s.solved {
+		help.Color = colornames.Gold
+		fmt.Fprint(help, "Solved! Enter: next level  Esc: level select")
+	} else {
+		fmt.Fprint(help, "Arrows: move  Z: undo  Y: redo  R: restart  Esc: level select")
+	}
+	help.Draw(win, pixel.IM.Moved(pixel.V(10, 5)))
+
+	// fit the board between the texts
+	areaH := win.Bounds().H() - 2*(txt.Bounds().H()+10)
+	unit := min(maxUnit, min(int(win.Bounds().W())/level.Width, int(areaH)/level.Height))
+	offset := pixel.V((win.Bounds().W()-float64(unit*level.Width))/2, (win.Bounds().H()-float64(unit*level.Height))/2)
+	// cell returns the rectangle of cell i, rows are counted from the top
+	cell := func(i int) pixel.Rect {
+		x, y := level.coords(i)
+		min := pixel.V(float64(x*unit), float64((level.Height-1-y)*unit)).Add(offset)
+		return pixel.Rect{Min: min, Max: min.Add(pixel.V(float64(unit), float64(unit)))}
+	}
+
+	imd := imdraw.New(nil)
+	for i := range level.walls {
+		rect := cell(i)
+		switch {
+		case level.walls[i]:
+			imd.Color = colornames.Sienna
+		case level.floor[i]:
+			imd.Color = colornames.Wheat
+		default:
+			continue
+		}
+		imd.Push(rect.Min, rect.Max)
+		imd.Rectangle(0)
+		if level.goals[i] {
+			imd.Color = colornames.Gold
+			imd.Push(rect.Center())
+			imd.Circle(float64(unit)/5, 0)
+		}
+		if s.game.boxes[i] {
+			imd.Color = colornames.Peru
+			if level.goals[i] {
+				imd.Color = colornames.Forestgreen
+			}
+			margin := pixel.V(float64(unit)/8, float64(unit)/8)
+			imd.Push(rect.Min.Add(margin), rect.Max.Sub(margin))
+			imd.Rectangle(0)
+		}
+	}
+	imd.Color = colornames.Royalblue
+	imd.Push(cell(s.game.player).Center())
+	imd.Circle(float64(unit)*3/8, 0)
+	imd.Draw(win)
+}
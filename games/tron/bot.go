@@ -0,0 +1,61 @@
+package tron
+
+import "github.com/miluchen/games-in-go/games/grid"
+
+// botAction picks the direction for cycle i that leaves it the most room to ride,
+// avoiding cells other cycles can reach on the next tick. Riding straight wins ties.
+func (a *Arena) botAction(i int) grid.Direction {
+	c := a.cycles[i]
+	best, bestScore := c.dir, -1
+	for _, dir := range []grid.Direction{c.dir, c.dir.Left(), c.dir.Right()} {
+		p := c.pos.Move(dir)
+		if !a.free(p) {
+			continue
+		}
+		score := a.room(p)
+		if a.contested(i, p) {
+			// a head-on crash is at best a draw, only take it if everything else is worse
+			score /= 4
+		}
+		if score > bestScore {
+			best, bestScore = dir, score
+		}
+	}
+	return best
+}
+
+// room counts the free cells reachable from p, p included
+func (a *Arena) room(p grid.Point) int {
+	visited := make([]bool, len(a.trails))
+	visited[a.index(p)] = true
+	count := 1
+	queue := []grid.Point{p}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, dir := range grid.Directions {
+			n := cur.Move(dir)
+			if a.free(n) && !visited[a.index(n)] {
+				visited[a.index(n)] = true
+				count++
+				queue = append(queue, n)
+			}
+		}
+	}
+	return count
+}
+
+// contested reports whether another cycle can ride onto p on the next tick
+func (a *Arena) contested(i int, p grid.Point) bool {
+	for j, other := range a.cycles {
+		if j == i || !other.alive {
+			continue
+		}
+		for _, dir := range grid.Directions {
+			if dir != other.dir.Opposite() && other.pos.Move(dir) == p {
+				return true
+			}
+		}
+	}
+	return false
+}
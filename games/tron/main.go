@@ -0,0 +1,64 @@
+package tron
+
+import (
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/colornames"
+	"golang.org/x/image/font/basicfont"
+)
+
+type GameState int
+
+const (
+	InGame GameState = iota
+	Exit
+)
+
+var atlas = text.NewAtlas(basicfont.Face7x13, text.ASCII)
+
+var gameState GameState
+var currentScene *Scene
+var setupMenu *SetupMenu
+
+// startGame starts a match with the options chosen in the setup menu
+func startGame() {
+	size := arenaSizes[setupMenu.arenaSize]
+	currentScene = newScene(newArena(size.width, size.height, setupMenu.bots[:setupMenu.players]))
+}
+
+// backToMenu leaves the current match and shows the setup menu
+func backToMenu() {
+	currentScene = nil
+}
+
+func run() {
+	gameState = InGame
+	currentScene = nil
+	setupMenu = newSetupMenu()
+	// initialize window
+	cfg := pixelgl.WindowConfig{
+		Title:  "tron",
+		Bounds: pixel.R(0, 0, 800, 600),
+		VSync:  true,
+	}
+	win, err := pixelgl.NewWindow(cfg)
+	if err != nil {
+		panic(err)
+	}
+	// game loop
+	for !win.Closed() && gameState != Exit {
+		win.Clear(colornames.Black)
+		if currentScene != nil {
+			currentScene.update(win)
+		} else {
+			setupMenu.update(win)
+		}
+		win.Update()
+	}
+}
+
+// Run starts tron light-cycles for 2-4 local players or bots
+func Run() {
+	pixelgl.Run(run)
+}
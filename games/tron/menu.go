@@ -0,0 +1,80 @@
+package tron
+
+import (
+	"fmt"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/colornames"
+)
+
+// arena sizes that can be chosen in the setup menu, in number of cells
+var arenaSizes = []struct {
+	name          string
+	width, height int
+}{
+	{"Small", 30, 22},
+	{"Medium", 50, 36},
+	{"Large", 80, 56},
+}
+
+// keys toggling whether a player is a bot
+var playerKeys = []pixelgl.Button{pixelgl.Key1, pixelgl.Key2, pixelgl.Key3, pixelgl.Key4}
+
+// SetupMenu chooses the players and the arena size
+type SetupMenu struct {
+	players   int              // number of cycles
+	bots      [MaxPlayers]bool // whether each player is controlled by the AI
+	arenaSize int              // index in arenaSizes
+}
+
+func newSetupMenu() *SetupMenu {
+	return &SetupMenu{players: MinPlayers, bots: [MaxPlayers]bool{false, true, true, true}, arenaSize: 1}
+}
+
+func (m *SetupMenu) update(win *pixelgl.Window) {
+	switch {
+	case win.JustPressed(pixelgl.KeyEscape):
+		gameState = Exit
+		return
+	case win.JustPressed(pixelgl.KeyEnter):
+		startGame()
+		return
+	case win.JustPressed(pixelgl.KeyP):
+		m.players++
+		if m.players > MaxPlayers {
+			m.players = MinPlayers
+		}
+	case win.JustPressed(pixelgl.KeyA):
+		m.arenaSize = (m.arenaSize + 1) % len(arenaSizes)
+	}
+	for i := 0; i < m.players; i++ {
+		if win.JustPressed(playerKeys[i]) {
+			m.bots[i] = !m.bots[i]
+		}
+	}
+	m.draw(win)
+}
+
+func (m *SetupMenu) draw(win *pixelgl.Window) {
+	win.Clear(colornames.Gray)
+	txt := text.New(pixel.ZV, atlas)
+	txt.Color = colornames.Green
+	fmt.Fprint(txt, "Tron\n\n")
+	txt.Color = colornames.White
+	fmt.Fprintf(txt, "P  Players:  %d\n", m.players)
+	size := arenaSizes[m.arenaSize]
+	fmt.Fprintf(txt, "A  Arena:    %s (%dx%d)\n\n", size.name, size.width, size.height)
+	for i := 0; i < m.players; i++ {
+		txt.Color = cycleColors[i]
+		control := controlNames[i]
+		if m.bots[i] {
+			control = "Bot"
+		}
+		fmt.Fprintf(txt, "%d  Player %d: %s\n", i+1, i+1, control)
+	}
+	txt.Color = colornames.Lightgray
+	fmt.Fprintf(txt, "\nFirst to win %d rounds wins the match\nEnter: play  Esc: exit", WinScore)
+	txt.Draw(win, pixel.IM.Scaled(pixel.ZV, 1.5).Moved(pixel.V(60, win.Bounds().H()-80)))
+}
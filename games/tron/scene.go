@@ -0,0 +1,144 @@
+package tron
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"time"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"github.com/miluchen/games-in-go/games/grid"
+	"golang.org/x/image/colornames"
+)
+
+const (
+	ticksPerSecond = 15 // number of cells the cycles ride per second
+	hudHeight      = 40 // space for the scores on top of the arena in pixels
+)
+
+// keys of each local player
+var controls = []map[pixelgl.Button]grid.Direction{
+	{pixelgl.KeyUp: grid.North, pixelgl.KeyRight: grid.East, pixelgl.KeyDown: grid.South, pixelgl.KeyLeft: grid.West},
+	{pixelgl.KeyW: grid.North, pixelgl.KeyD: grid.East, pixelgl.KeyS: grid.South, pixelgl.KeyA: grid.West},
+	{pixelgl.KeyI: grid.North, pixelgl.KeyL: grid.East, pixelgl.KeyK: grid.South, pixelgl.KeyJ: grid.West},
+	{pixelgl.KeyKP8: grid.North, pixelgl.KeyKP6: grid.East, pixelgl.KeyKP5: grid.South, pixelgl.KeyKP4: grid.West},
+}
+
+var controlNames = []string{"Arrow keys", "WASD", "IJKL", "Numpad 8456"}
+
+var cycleColors = []color.RGBA{colornames.Cyan, colornames.Orange, colornames.Magenta, colornames.Yellow}
+
+type Scene struct {
+	arena        *Arena
+	riding       bool      // whether the round is running, rounds start when Space is pressed
+	lastMoveTime time.Time // last timestamp the cycles moved
+}
+
+func newScene(arena *Arena) *Scene {
+	return &Scene{arena: arena}
+}
+
+func (s *Scene) update(win *pixelgl.Window) {
+	if win.JustPressed(pixelgl.KeyEscape) {
+		backToMenu()
+		return
+	}
+	a := s.arena
+	if !s.riding {
+		if win.JustPressed(pixelgl.KeySpace) {
+			if a.matchOver() {
+				startGame()
+				return
+			}
+			if a.over {
+				a.startRound()
+			}
+			s.riding = true
+			s.lastMoveTime = time.Now()
+		}
+		s.draw(win)
+		return
+	}
+	for i, c := range a.cycles {
+		if c.bot {
+			continue
+		}
+		for key, dir := range controls[i] {
+			if win.JustPressed(key) {
+				c.action = dir
+			}
+		}
+	}
+	// tick at a fixed rate whatever the frame rate is
+	interval := time.Second / ticksPerSecond
+	for time.Since(s.lastMoveTime) >= interval && !a.over {
+		a.tick()
+		s.lastMoveTime = s.lastMoveTime.Add(interval)
+	}
+	if a.over {
+		s.riding = false
+	}
+	s.draw(win)
+}
+
+func (s *Scene) status() string {
+	a := s.arena
+	switch {
+	case a.matchOver():
+		return fmt.Sprintf("Player %d wins the match! Space: new match  Esc: menu", a.winner+1)
+	case a.over && a.winner == -1:
+		return "Draw! Space: next round"
+	case a.over:
+		return fmt.Sprintf("Player %d wins round %d! Space: next round", a.winner+1, a.round)
+	case !s.riding:
+		return fmt.Sprintf("Round %d - Space: start", a.round)
+	}
+	return fmt.Sprintf("Round %d", a.round)
+}
+
+func (s *Scene) draw(win *pixelgl.Window) {
+	win.Clear(colornames.Black)
+	a := s.arena
+	unit := math.Floor(math.Min(win.Bounds().W()/float64(a.width+2), (win.Bounds().H()-hudHeight)/float64(a.height+2)))
+	offset := pixel.V((win.Bounds().W()-unit*float64(a.width))/2, (win.Bounds().H()-hudHeight-unit*float64(a.height))/2)
+	cell := func(p grid.Point) (pixel.Vec, pixel.Vec) {
+		corner := offset.Add(pixel.V(float64(p.X)*unit, float64(p.Y)*unit))
+		return corner, corner.Add(pixel.V(unit, unit))
+	}
+	imd := imdraw.New(nil)
+	// draw the wall
+	imd.Color = colornames.Steelblue
+	imd.Push(offset.Sub(pixel.V(2, 2)), offset.Add(pixel.V(unit*float64(a.width)+2, unit*float64(a.height)+2)))
+	imd.Rectangle(2)
+	// draw trails, dimmed, and the heads
+	for i, owner := range a.trails {
+		if owner == 0 {
+			continue
+		}
+		imd.Color = pixel.ToRGBA(cycleColors[owner-1]).Scaled(0.6)
+		imd.Push(cell(grid.Point{X: i % a.width, Y: i / a.width}))
+		imd.Rectangle(0)
+	}
+	for i, c := range a.cycles {
+		imd.Color = cycleColors[i]
+		if !c.alive {
+			imd.Color = colornames.Red
+		}
+		imd.Push(cell(c.pos))
+		imd.Rectangle(0)
+	}
+	imd.Draw(win)
+
+	// draw scores and status on top
+	txt := text.New(pixel.ZV, atlas)
+	for i, c := range a.cycles {
+		txt.Color = cycleColors[i]
+		fmt.Fprintf(txt, "P%d: %d   ", i+1, c.score)
+	}
+	txt.Color = colornames.White
+	fmt.Fprint(txt, s.status())
+	txt.Draw(win, pixel.IM.Moved(pixel.V(10, win.Bounds().H()-hudHeight/2-txt.LineHeight/2)))
+}
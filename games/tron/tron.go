@@ -0,0 +1,132 @@
+package tron
+
+import "github.com/miluchen/games-in-go/games/grid"
+
+const (
+	MinPlayers = 2
+	MaxPlayers = 4
+	WinScore   = 5 // number of rounds to win the match
+)
+
+type Cycle struct {
+	pos    grid.Point     // position of the head
+	dir    grid.Direction // moving direction
+	action grid.Direction // requested direction, applied on the next tick
+	alive  bool           // whether the cycle is still riding in this round
+	bot    bool           // whether the cycle is controlled by the AI
+	score  int            // number of rounds won
+}
+
+type Arena struct {
+	width  int
+	height int
+	trails []int // index+1 of the cycle whose trail is on a cell, 0 if the cell is free
+	cycles []*Cycle
+	round  int  // number of rounds played, the current one included
+	over   bool // whether the current round is over
+	winner int  // index of the cycle that won the last round, -1 for a draw
+}
+
+// newArena creates an arena of width x height cells with a cycle for each entry of bots,
+// which tells whether that cycle is controlled by the AI
+func newArena(width, height int, bots []bool) *Arena {
+	a := &Arena{width: width, height: height}
+	for _, bot := range bots {
+		a.cycles = append(a.cycles, &Cycle{bot: bot})
+	}
+	a.startRound()
+	return a
+}
+
+// startRound clears the trails and puts the cycles on their start positions:
+// west, east, south and north of the center, facing the center
+func (a *Arena) startRound() {
+	a.trails = make([]int, a.width*a.height)
+	a.round++
+	a.over = false
+	a.winner = -1
+	starts := []struct {
+		pos grid.Point
+		dir grid.Direction
+	}{
+		{grid.Point{X: a.width / 8, Y: a.height / 2}, grid.East},
+		{grid.Point{X: a.width - 1 - a.width/8, Y: a.height / 2}, grid.West},
+		{grid.Point{X: a.width / 2, Y: a.height / 8}, grid.North},
+		{grid.Point{X: a.width / 2, Y: a.height - 1 - a.height/8}, grid.South},
+	}
+	for i, c := range a.cycles {
+		c.pos, c.dir, c.action = starts[i].pos, starts[i].dir, starts[i].dir
+		c.alive = true
+		a.trails[a.index(c.pos)] = i + 1
+	}
+}
+
+func (a *Arena) index(p grid.Point) int {
+	return p.Y*a.width + p.X
+}
+
+// free reports whether a cycle can ride onto p
+func (a *Arena) free(p grid.Point) bool {
+	return p.In(a.width, a.height) && a.trails[a.index(p)] == 0
+}
+
+// matchOver reports whether a cycle has won the match
+func (a *Arena) matchOver() bool {
+	return a.over && a.winner != -1 && a.cycles[a.winner].score >= WinScore
+}
+
+// tick moves every cycle one cell at the same time. A cycle crashes into walls and trails,
+// and cycles riding onto the same cell crash into each other. The last cycle riding wins the round.
+func (a *Arena) tick() {
+	if a.over {
+		return
+	}
+	next := make([]grid.Point, len(a.cycles))
+	for i, c := range a.cycles {
+		if !c.alive {
+			continue
+		}
+		if c.bot {
+			c.action = a.botAction(i)
+		}
+		c.dir = grid.ChangeDirection(c.dir, c.action)
+		next[i] = c.pos.Move(c.dir)
+	}
+	crashed := make([]bool, len(a.cycles))
+	for i, c := range a.cycles {
+		if !c.alive {
+			continue
+		}
+		if !a.free(next[i]) {
+			crashed[i] = true
+		}
+		for j, other := range a.cycles {
+			if j != i && other.alive && next[j] == next[i] {
+				crashed[i] = true
+			}
+		}
+	}
+	riding := 0
+	for i, c := range a.cycles {
+		if !c.alive {
+			continue
+		}
+		if crashed[i] {
+			c.alive = false
+			continue
+		}
+		c.pos = next[i]
+		a.trails[a.index(c.pos)] = i + 1
+		riding++
+		a.winner = i
+	}
+	if riding > 1 {
+		return
+	}
+	a.over = true
+	if riding == 0 {
+		a.winner = -1
+		return
+	}
+	a.cycles[a.winner].score++
+}
@@ -3,21 +3,73 @@ package main
 import (
 	"flag"
 	"fmt"
+	"log"
+	"strings"
 
+	"github.com/miluchen/games-in-go/games/boardgames"
+	"github.com/miluchen/games-in-go/games/life"
+	"github.com/miluchen/games-in-go/games/reversi"
 	"github.com/miluchen/games-in-go/games/snake"
+	snakebench "github.com/miluchen/games-in-go/games/snake/bench"
+	"github.com/miluchen/games-in-go/games/snake/netplay"
+	"github.com/miluchen/games-in-go/games/sokoban"
+	"github.com/miluchen/games-in-go/games/tron"
 )
 
 const (
-	snakeGame = "snake"
+	snakeGame   = "snake"
+	sokobanGame = "sokoban"
+	lifeGame    = "life"
+	boardGames  = "boardgames"
+	tronGame    = "tron"
+	reversiGame = "reversi"
 )
 
-var game = flag.String("game", "", fmt.Sprintf("game: %s", snakeGame))
+var games = []string{snakeGame, sokobanGame, lifeGame, boardGames, tronGame, reversiGame}
+
+var game = flag.String("game", "", fmt.Sprintf("game: %s", strings.Join(games, ", ")))
+var levels = flag.String("levels", "", "sokoban: level file in XSB/.sok format, built-in levels are used if empty; snake: level file the campaign is played on, empty boards are used if empty")
+var pattern = flag.String("pattern", "", "life: pattern file in RLE or plaintext format to start with")
+var bench = flag.Bool("bench", false, "snake: play headless games with every AI strategy and report the results instead of starting the game")
+var runs = flag.Int("runs", 100, "snake: number of games each strategy plays in -bench")
+var seed = flag.Int64("seed", 1, "snake: seed of the first game in -bench, game i uses seed+i")
+var csvFile = flag.String("csv", "", "snake: file to also write the -bench results to as CSV")
+var bot = flag.String("bot", "", "snake: command of an external bot that plays, talking JSON lines over stdin/stdout")
+var serve = flag.String("serve", "", "snake: address to host online games on, e.g. :7777, no window is opened")
+var join = flag.String("join", "", "snake: address of the server to play an online game on")
+var name = flag.String("name", "", "snake: player name in online games")
+var leaderboardURL = flag.String("leaderboard", "", "snake: url of the leaderboard server to share the scores on, e.g. http://localhost:8080")
+var spectate = flag.String("spectate", "", "snake: address to stream the local games to spectators on, over TCP or WebSocket, e.g. localhost:7778")
 
 func main() {
 	flag.Parse()
 	switch *game {
 	case snakeGame:
-		snake.Run()
+		if *bench {
+			if err := snakebench.Run(*runs, *seed, *csvFile); err != nil {
+				log.Fatalf("bench failed: %v\n", err)
+			}
+			return
+		}
+		if *serve != "" {
+			server, err := netplay.Listen(*serve, netplay.Tick)
+			if err != nil {
+				log.Fatalf("listen failed: %v\n", err)
+			}
+			log.Printf("serving snake on %v\n", server.Addr())
+			log.Fatal(server.Serve())
+		}
+		snake.Run(snake.Options{Bot: *bot, Join: *join, Name: *name, Spectate: *spectate, Server: *leaderboardURL, Levels: *levels})
+	case sokobanGame:
+		sokoban.Run(*levels)
+	case lifeGame:
+		life.Run(*pattern)
+	case boardGames:
+		boardgames.Run()
+	case tronGame:
+		tron.Run()
+	case reversiGame:
+		reversi.Run()
 	default:
 		flag.Usage()
 	}